@@ -1,53 +1,96 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
+	"os/signal"
 	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/analytics"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/invites"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/recording"
 	"github.com/cankurttekin/sh.kurttekin.com/internal/server"
 )
 
 func main() {
-	config := server.DefaultConfig()
+	// flag values are only applied when set, so they override env and file
+	flags := server.DefaultConfig()
 
-	// overriding default values with command line flags 
-	flag.StringVar(&config.ListenAddr, "addr", config.ListenAddr, "SSH server address")
-	flag.StringVar(&config.KeyPath, "key", config.KeyPath, "Path to SSH server key (optional)")
-
-	var logFilePath string
-	flag.StringVar(&logFilePath, "log", "", "Path to connection log file (optional, default: tuiserver_connections.log)")
+	var configPath, addrs, hostKey string
+	flag.StringVar(&configPath, "config", os.Getenv(server.EnvPrefix+"CONFIG"), "Path to JSON config file (optional, env: TUISERVER_CONFIG)")
+	flag.StringVar(&addrs, "addr", strings.Join(flags.ListenAddrs, ","), "SSH server addresses, comma separated (e.g. :22,[::]:2222)")
+	flag.StringVar(&hostKey, "key", "", "Path to SSH server key (optional, generated when missing)")
+	flag.StringVar(&flags.ContentPath, "content", "", "Path to portfolio JSON content file (optional)")
+	flag.StringVar(&flags.Log.File, "log", "", "Path to connection log file (optional, default: tuiserver_connections.log)")
 
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] [command]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Commands:\n")
+		fmt.Fprintf(os.Stderr, "  config print    print the effective configuration, paths resolved, and exit\n")
+		fmt.Fprintf(os.Stderr, "  stats           print visitor statistics (stats -h for options)\n")
+		fmt.Fprintf(os.Stderr, "  ctl <command>   control the running server (ctl help for commands)\n")
+		fmt.Fprintf(os.Stderr, "  invite          create, list and revoke invites to private sections\n")
+		fmt.Fprintf(os.Stderr, "  replay <file>   play a session recording (replay -h for options)\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nSettings are merged in order of precedence:\n")
+		fmt.Fprintf(os.Stderr, "  flags > TUISERVER_* environment variables > config file > defaults\n")
+		fmt.Fprintf(os.Stderr, "\nLog and state files given as bare names (the defaults) are kept in\n")
+		fmt.Fprintf(os.Stderr, "state_dir: $STATE_DIRECTORY when systemd sets it through StateDirectory=,\n")
+		fmt.Fprintf(os.Stderr, "otherwise the working directory. Set a file to \"\" to turn it off.\n")
+		fmt.Fprintf(os.Stderr, "\nUnder systemd socket activation the SSH addresses are ignored and the\n")
+		fmt.Fprintf(os.Stderr, "passed sockets are used, by FileDescriptorName (ssh, http, telnet,\n")
+		fmt.Fprintf(os.Stderr, "finger, gemini, gopher, control); sockets with any other name serve SSH.\n")
+		fmt.Fprintf(os.Stderr, "\nSIGUSR2 starts a new process on the same sockets, for example after\n")
+		fmt.Fprintf(os.Stderr, "replacing the binary; the old one lets its sessions finish and exits.\n")
+		fmt.Fprintf(os.Stderr, "Run the systemd unit with Type=notify so the new process becomes the\n")
+		fmt.Fprintf(os.Stderr, "service's main process; with Type=simple systemd stops the service\n")
+		fmt.Fprintf(os.Stderr, "when the old process exits.\n")
 	}
 
 	flag.Parse()
 
-	// if log set override
-	if logFilePath != "" {
-		config.LogFile = logFilePath
-	}
+	// the same layers are read again by `ctl reload-config`
+	load := func() (server.Config, error) {
+		config, err := server.LoadConfig(configPath, os.LookupEnv)
+		if err != nil {
+			return config, err
+		}
 
-	// Handle relative paths for the log file
-	if config.LogFile != "" && !filepath.IsAbs(config.LogFile) {
-		// If it's a simple filename without directory separators, place it in the executable directory
-		if !strings.Contains(config.LogFile, "/") && !strings.Contains(config.LogFile, "\\") {
-			execPath, err := os.Executable()
-			if err == nil {
-				config.LogFile = filepath.Join(filepath.Dir(execPath), config.LogFile)
-			}
-		} else {
-			// If it contains path separators but is still relative, make it relative to current working directory
-			absPath, err := filepath.Abs(config.LogFile)
-			if err == nil {
-				config.LogFile = absPath
+		// overriding config values with command line flags that were set
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "addr":
+				config.ListenAddrs = strings.Split(addrs, ",")
+			case "key":
+				config.HostKeys = []string{hostKey}
+			case "content":
+				config.ContentPath = flags.ContentPath
+			case "log":
+				config.Log.File = flags.Log.File
 			}
-		}
+		})
+
+		config.ResolvePaths()
+		return config, nil
+	}
+
+	config, err := load()
+	if err != nil {
+		log.Fatalf("Config error: %v", err)
+	}
+	config.Reload = load
+
+	if args := flag.Args(); len(args) > 0 {
+		runCommand(config, args)
+		return
 	}
 
 	// Start the SSH server
@@ -55,3 +98,172 @@ func main() {
 		log.Fatalf("Server error: %v", err)
 	}
 }
+
+// runCommand handles the subcommands that do not start the server
+func runCommand(config server.Config, args []string) {
+	switch args[0] {
+	case "config":
+		if len(args) < 2 || args[1] != "print" {
+			log.Fatalf("Usage: %s config print", os.Args[0])
+		}
+		if err := config.WriteJSON(os.Stdout); err != nil {
+			log.Fatalf("Config error: %v", err)
+		}
+	case "stats":
+		runStats(config, args[1:])
+	case "replay":
+		runReplay(args[1:])
+	case "invite":
+		runInvite(config, args[1:])
+	case "ctl":
+		if err := server.Control(config.Control.Socket, args[1:], os.Stdout); err != nil {
+			log.Fatalf("Control error: %v", err)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// runStats prints the visitor report for a date range, the last 7 days by default
+func runStats(config server.Config, args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	today := time.Now().Format("2006-01-02")
+	from := fs.String("from", time.Now().AddDate(0, 0, -6).Format("2006-01-02"), "First day to include (YYYY-MM-DD)")
+	to := fs.String("to", today, "Last day to include (YYYY-MM-DD)")
+	top := fs.Int("top", 10, "Number of sections and links to list")
+	fs.Parse(args)
+
+	if config.Analytics.File == "" {
+		log.Fatalf("Analytics are disabled, set analytics.file in the config")
+	}
+
+	start, err := time.ParseInLocation("2006-01-02", *from, time.Local)
+	if err != nil {
+		log.Fatalf("Invalid -from date: %v", err)
+	}
+	end, err := time.ParseInLocation("2006-01-02", *to, time.Local)
+	if err != nil {
+		log.Fatalf("Invalid -to date: %v", err)
+	}
+	end = end.AddDate(0, 0, 1)
+
+	sessions, err := analytics.NewStore(config.Analytics.File).Sessions(start, end)
+	if err != nil {
+		log.Fatalf("Stats error: %v", err)
+	}
+
+	report := analytics.BuildReport(sessions, start, end, *top)
+	if err := report.Write(os.Stdout); err != nil {
+		log.Fatalf("Stats error: %v", err)
+	}
+}
+
+// runInvite manages the invite codes visitors connect with as their user
+// name to see private sections
+func runInvite(config server.Config, args []string) {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s invite create [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s invite list\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s invite revoke <code>\n", os.Args[0])
+	}
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+	if config.Private.InviteFile == "" {
+		log.Fatalf("Invites are disabled, set private.invite_file in the config")
+	}
+	store := invites.NewStore(config.Private.InviteFile)
+
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("invite create", flag.ExitOnError)
+		expires := fs.Duration("expires", 7*24*time.Hour, "How long the invite can be used, 0 never expires")
+		uses := fs.Int("uses", 1, "How many sessions may use the invite, 0 is unlimited")
+		note := fs.String("note", "", "Who the invite is for, shown in the list and the log")
+		fs.Usage = func() {
+			fmt.Fprintf(os.Stderr, "Usage: %s invite create [options]\n\nOptions:\n", os.Args[0])
+			fs.PrintDefaults()
+		}
+		fs.Parse(args[1:])
+		if *uses < 0 || *expires < 0 {
+			log.Fatalf("-uses and -expires cannot be negative")
+		}
+
+		var until time.Time
+		if *expires > 0 {
+			until = time.Now().Add(*expires)
+		}
+		inv, err := store.Create(*note, until, *uses)
+		if err != nil {
+			log.Fatalf("Invite error: %v", err)
+		}
+		fmt.Println(inv.Code)
+		fmt.Fprintf(os.Stderr, "Connect with: ssh %s@<host>\n", inv.Code)
+	case "list":
+		list, err := store.List()
+		if err != nil {
+			log.Fatalf("Invite error: %v", err)
+		}
+		if err := invites.WriteList(os.Stdout, list); err != nil {
+			log.Fatalf("Invite error: %v", err)
+		}
+	case "revoke":
+		if len(args) != 2 {
+			usage()
+			os.Exit(2)
+		}
+		if err := store.Revoke(args[1]); err != nil {
+			log.Fatalf("Invite error: %v", err)
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+// runReplay plays a session recording in this terminal
+func runReplay(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	speed := fs.Float64("speed", 1, "Playback speed multiplier")
+	idle := fs.Duration("idle", 2*time.Second, "Longest pause kept, 0 keeps every pause")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s replay [options] <file>\n\nOptions:\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		log.Fatalf("Replay error: %v", err)
+	}
+	defer f.Close()
+	player, err := recording.NewPlayer(f)
+	if err != nil {
+		log.Fatalf("Replay error: %v", err)
+	}
+
+	h := player.Header
+	if width, height, err := term.GetSize(int(os.Stdout.Fd())); err == nil && (width < h.Width || height < h.Height) {
+		fmt.Fprintf(os.Stderr, "Recorded at %dx%d, this terminal is %dx%d and may garble it.\n", h.Width, h.Height, width, height)
+		time.Sleep(2 * time.Second)
+	}
+
+	// stop on ctrl+c, then undo what the recording left switched on
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err = player.Play(ctx, os.Stdout, *speed, *idle)
+	fmt.Print(resetTerminal)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Fatalf("Replay error: %v", err)
+	}
+}
+
+// resetTerminal leaves the alternate screen and turns the mouse tracking
+// and hidden cursor of a recorded session off
+const resetTerminal = "\033[?1002l\033[?1006l\033[?1049l\033[?25h\033[0m"
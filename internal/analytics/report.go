@@ -0,0 +1,167 @@
+package analytics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Report summarizes the sessions of a date range
+type Report struct {
+	From, To      time.Time
+	Sessions      int
+	Days          []DayStats
+	UniqueKeys    int
+	MedianSession time.Duration
+	Sections      []SectionStats
+	Links         []Count
+}
+
+// DayStats counts the visits of one calendar day
+type DayStats struct {
+	Day      time.Time
+	Sessions int
+	Visitors int
+}
+
+// SectionStats is how often and how long a section was read
+type SectionStats struct {
+	Section string
+	Views   int
+	Dwell   time.Duration
+}
+
+// Count is a ranked value
+type Count struct {
+	Value string
+	Count int
+}
+
+// BuildReport aggregates sessions, keeping the top entries of each ranking
+func BuildReport(sessions []Session, from, to time.Time, top int) Report {
+	r := Report{From: from, To: to, Sessions: len(sessions)}
+
+	days := map[string]*DayStats{}
+	dayVisitors := map[string]map[string]bool{}
+	keys := map[string]bool{}
+	sections := map[string]*SectionStats{}
+	links := map[string]int{}
+	var durations []time.Duration
+
+	for _, s := range sessions {
+		day := s.Start.Local().Format("2006-01-02")
+		if days[day] == nil {
+			d, _ := time.ParseInLocation("2006-01-02", day, time.Local)
+			days[day] = &DayStats{Day: d}
+			dayVisitors[day] = map[string]bool{}
+		}
+		days[day].Sessions++
+		dayVisitors[day][s.Visitor] = true
+
+		if s.KeyFingerprint != "" {
+			keys[s.KeyFingerprint] = true
+		}
+		durations = append(durations, s.Duration())
+
+		for _, e := range s.Events {
+			switch e.Kind {
+			case EventSectionEnter:
+				sectionStats(sections, e.Section).Views++
+			case EventSectionLeave:
+				sectionStats(sections, e.Section).Dwell += e.Dwell
+			case EventLinkActivate:
+				links[e.Link]++
+			}
+		}
+	}
+
+	for day, d := range days {
+		d.Visitors = len(dayVisitors[day])
+		r.Days = append(r.Days, *d)
+	}
+	sort.Slice(r.Days, func(i, j int) bool { return r.Days[i].Day.Before(r.Days[j].Day) })
+
+	r.UniqueKeys = len(keys)
+	r.MedianSession = median(durations)
+
+	for _, s := range sections {
+		r.Sections = append(r.Sections, *s)
+	}
+	sort.Slice(r.Sections, func(i, j int) bool {
+		if r.Sections[i].Views != r.Sections[j].Views {
+			return r.Sections[i].Views > r.Sections[j].Views
+		}
+		return r.Sections[i].Section < r.Sections[j].Section
+	})
+	if top > 0 && len(r.Sections) > top {
+		r.Sections = r.Sections[:top]
+	}
+
+	r.Links = ranked(links, top)
+	return r
+}
+
+func sectionStats(sections map[string]*SectionStats, name string) *SectionStats {
+	if sections[name] == nil {
+		sections[name] = &SectionStats{Section: name}
+	}
+	return sections[name]
+}
+
+func median(durations []time.Duration) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	mid := len(durations) / 2
+	if len(durations)%2 == 0 {
+		return (durations[mid-1] + durations[mid]) / 2
+	}
+	return durations[mid]
+}
+
+func ranked(counts map[string]int, top int) []Count {
+	var out []Count
+	for v, c := range counts {
+		out = append(out, Count{Value: v, Count: c})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Value < out[j].Value
+	})
+	if top > 0 && len(out) > top {
+		out = out[:top]
+	}
+	return out
+}
+
+// Write prints the report as plain text tables
+func (r Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Visitor stats %s to %s\n\n", r.From.Format("2006-01-02"), r.To.AddDate(0, 0, -1).Format("2006-01-02"))
+	fmt.Fprintf(tw, "sessions:\t%d\n", r.Sessions)
+	fmt.Fprintf(tw, "unique public keys:\t%d\n", r.UniqueKeys)
+	fmt.Fprintf(tw, "median session length:\t%s\n\n", r.MedianSession.Round(time.Second))
+
+	fmt.Fprintln(tw, "DAY\tVISITORS\tSESSIONS")
+	for _, d := range r.Days {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", d.Day.Format("2006-01-02"), d.Visitors, d.Sessions)
+	}
+
+	fmt.Fprintln(tw, "\nSECTION\tVIEWS\tTIME READ")
+	for _, s := range r.Sections {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", s.Section, s.Views, s.Dwell.Round(time.Second))
+	}
+
+	fmt.Fprintln(tw, "\nLINK\tOPENED")
+	for _, l := range r.Links {
+		fmt.Fprintf(tw, "%s\t%d\n", l.Value, l.Count)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,137 @@
+package analytics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// session builds a session of visitor starting at start that lasted d
+func session(visitor, key string, start time.Time, d time.Duration, events ...Event) Session {
+	return Session{Visitor: visitor, KeyFingerprint: key, Start: start, End: start.Add(d), Events: events}
+}
+
+func enter(section string) Event { return Event{Kind: EventSectionEnter, Section: section} }
+
+func leave(section string, dwell time.Duration) Event {
+	return Event{Kind: EventSectionLeave, Section: section, Dwell: dwell}
+}
+
+func link(url string) Event { return Event{Kind: EventLinkActivate, Link: url} }
+
+func TestMedian(t *testing.T) {
+	tests := []struct {
+		durations []time.Duration
+		want      time.Duration
+	}{
+		{nil, 0},
+		{[]time.Duration{5}, 5},
+		{[]time.Duration{30, 10, 20}, 20},
+		{[]time.Duration{40, 10, 30, 20}, 25},
+		{[]time.Duration{1, 1, 1, 100}, 1},
+	}
+	for _, tt := range tests {
+		if got := median(tt.durations); got != tt.want {
+			t.Errorf("median(%v) = %v, want %v", tt.durations, got, tt.want)
+		}
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	day1 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	sessions := []Session{
+		session("key:a", "SHA256:a", day1, time.Minute,
+			enter("About"), leave("About", 40*time.Second), enter("Projects"), link("https://a.example")),
+		session("key:a", "SHA256:a", day1.Add(time.Hour), 3*time.Minute,
+			enter("Projects"), leave("Projects", 2*time.Minute), link("https://a.example"), link("https://b.example")),
+		session("ip:1", "", day1.Add(2*time.Hour), 10*time.Second, enter("About")),
+		session("key:b", "SHA256:b", day2, 2*time.Minute,
+			enter("Contact"), enter("About"), leave("About", 5*time.Second), link("https://c.example")),
+	}
+
+	r := BuildReport(sessions, day1, day2.AddDate(0, 0, 1), 2)
+
+	if r.Sessions != 4 {
+		t.Errorf("Sessions = %d, want 4", r.Sessions)
+	}
+	if r.UniqueKeys != 2 {
+		t.Errorf("UniqueKeys = %d, want 2", r.UniqueKeys)
+	}
+	// 10s, 1m, 2m, 3m
+	if want := 90 * time.Second; r.MedianSession != want {
+		t.Errorf("MedianSession = %v, want %v", r.MedianSession, want)
+	}
+
+	wantDays := []DayStats{
+		{Day: time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local), Sessions: 3, Visitors: 2},
+		{Day: time.Date(2024, 5, 2, 0, 0, 0, 0, time.Local), Sessions: 1, Visitors: 1},
+	}
+	if !reflect.DeepEqual(r.Days, wantDays) {
+		t.Errorf("Days = %+v, want %+v", r.Days, wantDays)
+	}
+
+	// the top 2 of About (3 views), Projects (2) and Contact (1)
+	wantSections := []SectionStats{
+		{Section: "About", Views: 3, Dwell: 45 * time.Second},
+		{Section: "Projects", Views: 2, Dwell: 2 * time.Minute},
+	}
+	if !reflect.DeepEqual(r.Sections, wantSections) {
+		t.Errorf("Sections = %+v, want %+v", r.Sections, wantSections)
+	}
+
+	// ties are ranked by value
+	wantLinks := []Count{{"https://a.example", 2}, {"https://b.example", 1}}
+	if !reflect.DeepEqual(r.Links, wantLinks) {
+		t.Errorf("Links = %+v, want %+v", r.Links, wantLinks)
+	}
+}
+
+func TestBuildReportEmpty(t *testing.T) {
+	r := BuildReport(nil, time.Time{}, time.Time{}, 10)
+	if r.Sessions != 0 || r.UniqueKeys != 0 || r.MedianSession != 0 || r.Days != nil || r.Sections != nil || r.Links != nil {
+		t.Errorf("report of no sessions = %+v", r)
+	}
+}
+
+func TestRanked(t *testing.T) {
+	counts := map[string]int{"b": 2, "a": 2, "c": 5, "d": 1}
+	tests := []struct {
+		top  int
+		want []Count
+	}{
+		{0, []Count{{"c", 5}, {"a", 2}, {"b", 2}, {"d", 1}}},
+		{2, []Count{{"c", 5}, {"a", 2}}},
+		{10, []Count{{"c", 5}, {"a", 2}, {"b", 2}, {"d", 1}}},
+	}
+	for _, tt := range tests {
+		if got := ranked(counts, tt.top); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ranked(top %d) = %v, want %v", tt.top, got, tt.want)
+		}
+	}
+}
+
+func TestReportWrite(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.Local)
+	r := BuildReport([]Session{
+		session("key:a", "SHA256:a", day.Add(time.Hour), 90*time.Second, enter("About"), link("https://a.example")),
+	}, day, day.AddDate(0, 0, 7), 10)
+
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Visitor stats 2024-05-01 to 2024-05-07",
+		"unique public keys:     1",
+		"median session length:  1m30s",
+		"2024-05-01  1         1",
+		"About    1      0s",
+		"https://a.example  1",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("report lacks %q\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package analytics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// event kinds, matching the ones reported by the TUI
+const (
+	EventSectionEnter = "section_enter"
+	EventSectionLeave = "section_leave"
+	EventLinkMode     = "link_mode"
+	EventLinkActivate = "link_activate"
+)
+
+var sessionsBucket = []byte("sessions")
+
+// keyFormat is fixed width so keys sort chronologically
+const keyFormat = "2006-01-02T15:04:05.000000000Z"
+
+// Session is everything recorded about one visit
+type Session struct {
+	ID             string    `json:"id"`
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	Visitor        string    `json:"visitor"`                   // key fingerprint, or a keyed hash of the IP
+	KeyFingerprint string    `json:"key_fingerprint,omitempty"` // SHA256 fingerprint of the client key
+	User           string    `json:"user"`
+	Term           string    `json:"term"`
+	ExitReason     string    `json:"exit_reason"`
+	Events         []Event   `json:"events"`
+}
+
+// Event is one interaction within a session
+type Event struct {
+	Kind    string        `json:"kind"`
+	Time    time.Time     `json:"time"`
+	Section string        `json:"section,omitempty"`
+	Link    string        `json:"link,omitempty"`
+	Dwell   time.Duration `json:"dwell,omitempty"`
+	Enabled bool          `json:"enabled,omitempty"`
+}
+
+// Duration is how long the session lasted
+func (s Session) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Store is a bbolt database of sessions. The file is opened per call so
+// that `tuiserver stats` can read it while the server is running.
+type Store struct {
+	path string
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+func (st *Store) open(readOnly bool) (*bolt.DB, error) {
+	db, err := bolt.Open(st.path, 0600, &bolt.Options{
+		Timeout:  5 * time.Second,
+		ReadOnly: readOnly,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open analytics store %s: %w", st.path, err)
+	}
+	return db, nil
+}
+
+// sessionKey orders sessions by start time
+func sessionKey(s Session) []byte {
+	return []byte(s.Start.UTC().Format(keyFormat) + "/" + s.ID)
+}
+
+// Save records a finished session
+func (st *Store) Save(s Session) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	db, err := st.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(sessionsBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put(sessionKey(s), data)
+	})
+}
+
+// Sessions returns the sessions that started in [from, to)
+func (st *Store) Sessions(from, to time.Time) ([]Session, error) {
+	if _, err := os.Stat(st.path); errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+
+	db, err := st.open(true)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var sessions []Session
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(sessionsBucket)
+		if b == nil {
+			return nil
+		}
+
+		start := []byte(from.UTC().Format(keyFormat))
+		end := to.UTC().Format(keyFormat)
+
+		c := b.Cursor()
+		for k, v := c.Seek(start); k != nil && string(k) < end; k, v = c.Next() {
+			var s Session
+			if err := json.Unmarshal(v, &s); err != nil {
+				return fmt.Errorf("corrupt session record %s: %w", k, err)
+			}
+			sessions = append(sessions, s)
+		}
+		return nil
+	})
+	return sessions, err
+}
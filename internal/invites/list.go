@@ -0,0 +1,36 @@
+package invites
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteList prints invites as a table
+func WriteList(w io.Writer, list []Invite) error {
+	if len(list) == 0 {
+		_, err := fmt.Fprintln(w, "no invites")
+		return err
+	}
+
+	now := time.Now()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CODE\tSTATUS\tUSES\tEXPIRES\tLAST USED\tNOTE")
+	for _, inv := range list {
+		uses := fmt.Sprintf("%d", len(inv.Uses))
+		if inv.MaxUses > 0 {
+			uses += fmt.Sprintf("/%d", inv.MaxUses)
+		}
+		expires := "never"
+		if !inv.Expires.IsZero() {
+			expires = inv.Expires.Local().Format("2006-01-02 15:04")
+		}
+		lastUsed := "-"
+		if n := len(inv.Uses); n > 0 {
+			lastUsed = inv.Uses[n-1].Time.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", inv.Code, inv.Status(now), uses, expires, lastUsed, inv.Note)
+	}
+	return tw.Flush()
+}
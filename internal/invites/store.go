@@ -0,0 +1,236 @@
+// Package invites keeps the invite codes that unlock private sections for
+// visitors who connect with them as their SSH user name, e.g.
+// `ssh inv-mfrggzdfmztwq2lknnwg23tpoa@host`.
+package invites
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Prefix starts every invite code
+const Prefix = "inv-"
+
+// random bytes in a code, enough that codes cannot be guessed even when
+// guessing is not slowed down by bans
+const codeBytes = 16
+
+// codeEncoding writes codes in lower case, which SSH user names keep
+var codeEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+var invitesBucket = []byte("invites")
+
+// reasons an invite is refused
+var (
+	ErrUnknown = errors.New("unknown invite")
+	ErrExpired = errors.New("invite has expired")
+	ErrUsedUp  = errors.New("invite has been used up")
+)
+
+// Invite is a code that may be used a limited number of times until it
+// expires
+type Invite struct {
+	Code    string    `json:"code"`
+	Note    string    `json:"note,omitempty"` // who it was made for
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires,omitempty"` // zero never expires
+	MaxUses int       `json:"max_uses"`          // 0 is unlimited
+	Uses    []Use     `json:"uses,omitempty"`
+}
+
+// Use is one session started with an invite
+type Use struct {
+	Time           time.Time `json:"time"`
+	KeyFingerprint string    `json:"key_fingerprint,omitempty"`
+}
+
+// IsCode reports whether an SSH user name looks like an invite code
+func IsCode(user string) bool {
+	return strings.HasPrefix(strings.ToLower(user), Prefix)
+}
+
+// redactedChars of a code are kept by Redact, enough to tell invites apart
+// in `invite list` but far too few to use one
+const redactedChars = 4
+
+// Redact returns user with most of an invite code cut off, for logs,
+// analytics and anything else someone other than the visitor may read.
+// Other user names are returned as they are.
+func Redact(user string) string {
+	if !IsCode(user) {
+		return user
+	}
+	code := []rune(strings.ToLower(user)[len(Prefix):])
+	if len(code) > redactedChars {
+		code = code[:redactedChars]
+	}
+	return Prefix + string(code) + "…"
+}
+
+// check reports why the invite cannot be used at now, nil when it can
+func (inv Invite) check(now time.Time) error {
+	if !inv.Expires.IsZero() && now.After(inv.Expires) {
+		return ErrExpired
+	}
+	if inv.MaxUses > 0 && len(inv.Uses) >= inv.MaxUses {
+		return ErrUsedUp
+	}
+	return nil
+}
+
+// Status describes whether the invite can still be used
+func (inv Invite) Status(now time.Time) string {
+	switch inv.check(now) {
+	case ErrExpired:
+		return "expired"
+	case ErrUsedUp:
+		return "used up"
+	}
+	return "active"
+}
+
+// Store is a bbolt database of invites, opened per call so that
+// `tuiserver invite` can change it while the server is running
+type Store struct {
+	path string
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+func (st *Store) update(fn func(b *bolt.Bucket) error) error {
+	db, err := bolt.Open(st.path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return fmt.Errorf("failed to open invite store %s: %w", st.path, err)
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(invitesBucket)
+		if err != nil {
+			return err
+		}
+		return fn(b)
+	})
+}
+
+// view reads the invites without taking the write lock, fn gets a nil
+// bucket when there are no invites yet
+func (st *Store) view(fn func(b *bolt.Bucket) error) error {
+	if _, err := os.Stat(st.path); errors.Is(err, fs.ErrNotExist) {
+		return fn(nil)
+	}
+	db, err := bolt.Open(st.path, 0600, &bolt.Options{Timeout: 5 * time.Second, ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to open invite store %s: %w", st.path, err)
+	}
+	defer db.Close()
+
+	return db.View(func(tx *bolt.Tx) error {
+		return fn(tx.Bucket(invitesBucket))
+	})
+}
+
+func get(b *bolt.Bucket, code string) (Invite, error) {
+	var inv Invite
+	data := b.Get([]byte(code))
+	if data == nil {
+		return inv, ErrUnknown
+	}
+	if err := json.Unmarshal(data, &inv); err != nil {
+		return inv, fmt.Errorf("corrupt invite %s: %w", code, err)
+	}
+	return inv, nil
+}
+
+func put(b *bolt.Bucket, inv Invite) error {
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(inv.Code), data)
+}
+
+// Create makes a new invite with a random code
+func (st *Store) Create(note string, expires time.Time, maxUses int) (Invite, error) {
+	random := make([]byte, codeBytes)
+	if _, err := rand.Read(random); err != nil {
+		return Invite{}, err
+	}
+	inv := Invite{
+		Code:    Prefix + codeEncoding.EncodeToString(random),
+		Note:    note,
+		Created: time.Now(),
+		Expires: expires,
+		MaxUses: maxUses,
+	}
+
+	err := st.update(func(b *bolt.Bucket) error {
+		if b.Get([]byte(inv.Code)) != nil {
+			return errors.New("invite code collision, try again")
+		}
+		return put(b, inv)
+	})
+	return inv, err
+}
+
+// Use records a session started with the invite code, returning the
+// invite or why it was refused
+func (st *Store) Use(code, fingerprint string) (Invite, error) {
+	var inv Invite
+	err := st.update(func(b *bolt.Bucket) error {
+		var err error
+		if inv, err = get(b, strings.ToLower(code)); err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := inv.check(now); err != nil {
+			return err
+		}
+		inv.Uses = append(inv.Uses, Use{Time: now, KeyFingerprint: fingerprint})
+		return put(b, inv)
+	})
+	return inv, err
+}
+
+// Revoke deletes an invite
+func (st *Store) Revoke(code string) error {
+	code = strings.ToLower(code)
+	return st.update(func(b *bolt.Bucket) error {
+		if b.Get([]byte(code)) == nil {
+			return ErrUnknown
+		}
+		return b.Delete([]byte(code))
+	})
+}
+
+// List returns every invite, newest first
+func (st *Store) List() ([]Invite, error) {
+	var list []Invite
+	err := st.view(func(b *bolt.Bucket) error {
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var inv Invite
+			if err := json.Unmarshal(v, &inv); err != nil {
+				return fmt.Errorf("corrupt invite %s: %w", k, err)
+			}
+			list = append(list, inv)
+			return nil
+		})
+	})
+	sort.Slice(list, func(i, j int) bool { return list[i].Created.After(list[j].Created) })
+	return list, err
+}
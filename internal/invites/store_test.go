@@ -0,0 +1,179 @@
+package invites
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) *Store {
+	return NewStore(filepath.Join(t.TempDir(), "invites.db"))
+}
+
+func TestCreate(t *testing.T) {
+	st := newTestStore(t)
+	seen := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		inv, err := st.Create("", time.Time{}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsCode(inv.Code) || len(inv.Code) != len(Prefix)+26 || inv.Code != strings.ToLower(inv.Code) {
+			t.Errorf("code %q is not a lowercase %d byte code", inv.Code, codeBytes)
+		}
+		if seen[inv.Code] {
+			t.Errorf("code %q created twice", inv.Code)
+		}
+		seen[inv.Code] = true
+	}
+}
+
+func TestUse(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		expires time.Time
+		maxUses int
+		uses    int   // successful uses expected before err
+		err     error // from the use after those
+	}{
+		{"single use", time.Time{}, 1, 1, ErrUsedUp},
+		{"three uses", future, 3, 3, ErrUsedUp},
+		{"expired", past, 0, 0, ErrExpired},
+		{"expired beats used up", past, 1, 0, ErrExpired},
+		{"unlimited", time.Time{}, 0, 10, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newTestStore(t)
+			created, err := st.Create("for a test", tt.expires, tt.maxUses)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tt.uses; i++ {
+				inv, err := st.Use(created.Code, "SHA256:key")
+				if err != nil {
+					t.Fatalf("use %d: %v", i+1, err)
+				}
+				if len(inv.Uses) != i+1 || inv.Uses[i].KeyFingerprint != "SHA256:key" {
+					t.Fatalf("use %d recorded %+v", i+1, inv.Uses)
+				}
+			}
+			inv, err := st.Use(created.Code, "")
+			if tt.err == nil {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("use after %d = %v, want %v", tt.uses, err, tt.err)
+			}
+			if inv.Note != "for a test" {
+				t.Errorf("refused use returned %+v, want the invite", inv)
+			}
+		})
+	}
+}
+
+func TestCodesIgnoreCase(t *testing.T) {
+	st := newTestStore(t)
+	inv, err := st.Create("", time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := st.Use(strings.ToUpper(inv.Code), ""); err != nil {
+		t.Errorf("Use with an upper case code: %v", err)
+	}
+	if err := st.Revoke(strings.ToUpper(inv.Code)); err != nil {
+		t.Errorf("Revoke with an upper case code: %v", err)
+	}
+	if _, err := st.Use(inv.Code, ""); !errors.Is(err, ErrUnknown) {
+		t.Errorf("Use after Revoke = %v, want %v", err, ErrUnknown)
+	}
+	if err := st.Revoke(inv.Code); !errors.Is(err, ErrUnknown) {
+		t.Errorf("second Revoke = %v, want %v", err, ErrUnknown)
+	}
+}
+
+func TestList(t *testing.T) {
+	st := newTestStore(t)
+	list, err := st.List()
+	if err != nil || len(list) != 0 {
+		t.Fatalf("List without a store = %v, %v", list, err)
+	}
+
+	for _, note := range []string{"first", "second", "third"} {
+		if _, err := st.Create(note, time.Time{}, 0); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	list, err = st.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var notes []string
+	for _, inv := range list {
+		notes = append(notes, inv.Note)
+	}
+	if got := strings.Join(notes, ","); got != "third,second,first" {
+		t.Errorf("List = %s, want newest first", got)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		inv  Invite
+		want string
+	}{
+		{Invite{}, "active"},
+		{Invite{Expires: now.Add(time.Minute), MaxUses: 2, Uses: []Use{{}}}, "active"},
+		{Invite{Expires: now.Add(-time.Minute)}, "expired"},
+		{Invite{MaxUses: 1, Uses: []Use{{}}}, "used up"},
+	}
+	for _, tt := range tests {
+		if got := tt.inv.Status(now); got != tt.want {
+			t.Errorf("Status(%+v) = %q, want %q", tt.inv, got, tt.want)
+		}
+	}
+}
+
+func TestIsCode(t *testing.T) {
+	tests := map[string]bool{
+		"inv-abc":  true,
+		"INV-ABC":  true,
+		"invite":   false,
+		"alice":    false,
+		"":         false,
+		"xinv-abc": false,
+	}
+	for user, want := range tests {
+		if got := IsCode(user); got != want {
+			t.Errorf("IsCode(%q) = %v, want %v", user, got, want)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		user, want string
+	}{
+		{"inv-abcdefghijklmnopqrstuvwxyz", "inv-abcd…"},
+		{"INV-ABCDEFGH", "inv-abcd…"},
+		{"inv-ab", "inv-ab…"},
+		{"inv-", "inv-…"},
+		{"alice", "alice"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Redact(tt.user); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.user, got, tt.want)
+		}
+	}
+}
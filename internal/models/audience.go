@@ -0,0 +1,186 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Audience is a variant of the content for one kind of visitor, picked by
+// the SSH user name they connect with, e.g. `ssh recruiter@host`
+type Audience struct {
+	Name   string   `json:"name"`             // SSH user name selecting the audience
+	Order  []string `json:"order,omitempty"`  // section titles shown first, in this order
+	Expand []string `json:"expand,omitempty"` // sections shown with their details
+}
+
+// lineTag matches the audience tag a content line may start with:
+// "[+dev,friend] text" is only shown to those audiences, "[-recruiter] text"
+// to every audience but those
+var lineTag = regexp.MustCompile(`^\[([+-])([a-z0-9_.-]+(?:,[a-z0-9_.-]+)*)\] ?`)
+
+// Audience returns the audience for an SSH user name, the default audience
+// for other names, or nil when there is none
+func (p Portfolio) Audience(user string) *Audience {
+	if a := p.AudienceNamed(strings.ToLower(user)); a != nil {
+		return a
+	}
+	return p.AudienceNamed(p.DefaultAudience)
+}
+
+// AudienceNamed returns the audience called name, nil when there is none
+func (p Portfolio) AudienceNamed(name string) *Audience {
+	for i := range p.Audiences {
+		if name != "" && p.Audiences[i].Name == name {
+			return &p.Audiences[i]
+		}
+	}
+	return nil
+}
+
+// ForAudience returns the content as the audience for user sees it:
+// sections and lines tagged for other audiences dropped, tags removed,
+// details of expanded sections added and the audience's sections first.
+// Without an audience only untagged and exclude-tagged content is shown.
+func (p Portfolio) ForAudience(user string) Portfolio {
+	audience := p.Audience(user)
+	name := ""
+	if audience != nil {
+		name = audience.Name
+	}
+
+	sections := make([]Section, 0, len(p.Sections))
+	for _, sec := range p.Sections {
+		if !sec.shownTo(name) {
+			continue
+		}
+		lines := sec.Content
+		if audience != nil && contains(audience.Expand, sec.Title) {
+			lines = append(append([]string(nil), lines...), sec.Details...)
+		}
+		sec.Content = filterLines(lines, name)
+		sec.Details = nil
+		sections = append(sections, sec)
+	}
+
+	if audience != nil && len(audience.Order) > 0 {
+		ordered := make([]Section, 0, len(sections))
+		for _, title := range audience.Order {
+			for _, sec := range sections {
+				if sec.Title == title {
+					ordered = append(ordered, sec)
+				}
+			}
+		}
+		for _, sec := range sections {
+			if !contains(audience.Order, sec.Title) {
+				ordered = append(ordered, sec)
+			}
+		}
+		sections = ordered
+	}
+
+	p.Sections = sections
+	return p
+}
+
+// shownTo reports whether the section is for the audience called name
+func (sec Section) shownTo(name string) bool {
+	if len(sec.Audiences) > 0 && !contains(sec.Audiences, name) {
+		return false
+	}
+	return !contains(sec.Exclude, name)
+}
+
+// filterLines drops the lines tagged for other audiences and removes the
+// tags from the rest
+func filterLines(lines []string, name string) []string {
+	filtered := make([]string, 0, len(lines))
+	for _, line := range lines {
+		tag := lineTag.FindStringSubmatch(line)
+		if tag == nil {
+			filtered = append(filtered, line)
+			continue
+		}
+		listed := contains(strings.Split(tag[2], ","), name)
+		if (tag[1] == "+") == listed {
+			filtered = append(filtered, line[len(tag[0]):])
+		}
+	}
+	return filtered
+}
+
+// validateAudiences checks that audiences are named once, that tags name
+// known audiences and that every audience is left with a section to see
+// without private access
+func (p Portfolio) validateAudiences() error {
+	known := map[string]bool{}
+	for i, a := range p.Audiences {
+		if a.Name == "" || a.Name != strings.ToLower(a.Name) {
+			return fmt.Errorf("audience %d needs a lowercase name", i+1)
+		}
+		if known[a.Name] {
+			return fmt.Errorf("audience %q is defined twice", a.Name)
+		}
+		known[a.Name] = true
+	}
+	if p.DefaultAudience != "" && !known[p.DefaultAudience] {
+		return fmt.Errorf("default audience %q is not defined", p.DefaultAudience)
+	}
+
+	check := func(where string, names []string) error {
+		for _, name := range names {
+			if !known[name] {
+				return fmt.Errorf("%s names unknown audience %q", where, name)
+			}
+		}
+		return nil
+	}
+	for _, sec := range p.Sections {
+		where := fmt.Sprintf("section %q", sec.Title)
+		if err := check(where, sec.Audiences); err != nil {
+			return err
+		}
+		if err := check(where, sec.Exclude); err != nil {
+			return err
+		}
+		for _, line := range append(append([]string(nil), sec.Content...), sec.Details...) {
+			if tag := lineTag.FindStringSubmatch(line); tag != nil {
+				if err := check(where+" line tag", strings.Split(tag[2], ",")); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	names := keys(known)
+	sort.Strings(names)
+	for _, name := range append([]string{""}, names...) {
+		if len(p.Visible().Public().ForAudience(name).Sections) == 0 {
+			if name == "" {
+				return errors.New("visitors without an audience of their own have no visible sections")
+			}
+			return fmt.Errorf("audience %q has no visible sections", name)
+		}
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func keys(set map[string]bool) []string {
+	list := make([]string, 0, len(set))
+	for k := range set {
+		list = append(list, k)
+	}
+	return list
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func audiencePortfolio() Portfolio {
+	return Portfolio{
+		Title: "test",
+		Sections: []Section{
+			{Title: "About", Content: []string{"hello", "[+dev] go and rust", "[-recruiter] side projects", "[+dev,friend] dotfiles"}, Details: []string{"more"}},
+			{Title: "Work", Content: []string{"acme"}, Details: []string{"2019-2024"}},
+			{Title: "Hiring", Content: []string{"open to offers"}, Audiences: []string{"recruiter"}},
+			{Title: "Fun", Content: []string{"games"}, Exclude: []string{"recruiter"}},
+		},
+		Audiences: []Audience{
+			{Name: "recruiter", Order: []string{"Hiring", "Work"}, Expand: []string{"Work"}},
+			{Name: "dev"},
+			{Name: "friend"},
+		},
+	}
+}
+
+func TestForAudience(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		fallback string // DefaultAudience
+		titles   []string
+		about    []string
+		work     []string
+	}{
+		{"no audience", "alice", "", []string{"About", "Work", "Fun"}, []string{"hello", "side projects"}, []string{"acme"}},
+		{"dev", "dev", "", []string{"About", "Work", "Fun"}, []string{"hello", "go and rust", "side projects", "dotfiles"}, []string{"acme"}},
+		{"user names ignore case", "DEV", "", []string{"About", "Work", "Fun"}, []string{"hello", "go and rust", "side projects", "dotfiles"}, []string{"acme"}},
+		{"friend", "friend", "", []string{"About", "Work", "Fun"}, []string{"hello", "side projects", "dotfiles"}, []string{"acme"}},
+		{"recruiter ordered and expanded", "recruiter", "", []string{"Hiring", "Work", "About"}, []string{"hello"}, []string{"acme", "2019-2024"}},
+		{"default audience", "alice", "friend", []string{"About", "Work", "Fun"}, []string{"hello", "side projects", "dotfiles"}, []string{"acme"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := audiencePortfolio()
+			p.DefaultAudience = tt.fallback
+			got := p.ForAudience(tt.user)
+
+			var titles []string
+			sections := map[string]Section{}
+			for _, sec := range got.Sections {
+				titles = append(titles, sec.Title)
+				sections[sec.Title] = sec
+				if sec.Details != nil {
+					t.Errorf("section %q kept its details", sec.Title)
+				}
+			}
+			if !reflect.DeepEqual(titles, tt.titles) {
+				t.Errorf("sections = %v, want %v", titles, tt.titles)
+			}
+			if about := sections["About"].Content; !reflect.DeepEqual(about, tt.about) {
+				t.Errorf("About = %q, want %q", about, tt.about)
+			}
+			if work := sections["Work"].Content; !reflect.DeepEqual(work, tt.work) {
+				t.Errorf("Work = %q, want %q", work, tt.work)
+			}
+		})
+	}
+}
+
+// ForAudience must not change the content it was called on
+func TestForAudienceKeepsContent(t *testing.T) {
+	p := audiencePortfolio()
+	before := p.Clone()
+	p.ForAudience("recruiter")
+	p.ForAudience("dev")
+	if !reflect.DeepEqual(p, before) {
+		t.Error("ForAudience changed the portfolio")
+	}
+}
+
+func TestValidateAudiences(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(p *Portfolio)
+		err    string // part of the error, "" for valid content
+	}{
+		{"valid", func(p *Portfolio) {}, ""},
+		{"unnamed", func(p *Portfolio) { p.Audiences[0].Name = "" }, "needs a lowercase name"},
+		{"upper case", func(p *Portfolio) { p.Audiences[0].Name = "Dev" }, "needs a lowercase name"},
+		{"twice", func(p *Portfolio) { p.Audiences[1].Name = "recruiter" }, "defined twice"},
+		{"unknown default", func(p *Portfolio) { p.DefaultAudience = "ops" }, `default audience "ops"`},
+		{"unknown section audience", func(p *Portfolio) { p.Sections[2].Audiences = []string{"ops"} }, `unknown audience "ops"`},
+		{"unknown exclude", func(p *Portfolio) { p.Sections[3].Exclude = []string{"ops"} }, `unknown audience "ops"`},
+		{"unknown line tag", func(p *Portfolio) { p.Sections[0].Content = append(p.Sections[0].Content, "[+ops] x") }, "line tag"},
+		{"audience left without sections", func(p *Portfolio) {
+			for i := range p.Sections {
+				p.Sections[i].Exclude = []string{"dev"}
+				p.Sections[i].Audiences = nil
+			}
+		}, `audience "dev" has no visible sections`},
+		{"only private sections", func(p *Portfolio) {
+			for i := range p.Sections {
+				p.Sections[i].Private = true
+			}
+		}, "no visible sections"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := audiencePortfolio()
+			tt.change(&p)
+			err := p.validateAudiences()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
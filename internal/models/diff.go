@@ -0,0 +1,74 @@
+package models
+
+import "strings"
+
+// kinds of SectionChange
+const (
+	SectionAdded   = "new"
+	SectionChanged = "changed"
+	SectionRemoved = "removed"
+)
+
+// SectionChange is a section that differs between two versions of the
+// content. Sections are matched by title, so a renamed section shows up
+// as removed and added.
+type SectionChange struct {
+	Section string
+	Kind    string   // SectionAdded, SectionChanged or SectionRemoved
+	Added   []string // lines that are new or were edited
+	Removed int      // lines that are gone or were edited
+}
+
+// Diff lists the sections that changed from old to p, in the order of p
+// with removed sections last
+func (p Portfolio) Diff(old Portfolio) []SectionChange {
+	previous := make(map[string]Section, len(old.Sections))
+	for _, sec := range old.Sections {
+		previous[sec.Title] = sec
+	}
+
+	var changes []SectionChange
+	seen := make(map[string]bool, len(p.Sections))
+	for _, sec := range p.Sections {
+		seen[sec.Title] = true
+		before, ok := previous[sec.Title]
+		if !ok {
+			changes = append(changes, SectionChange{Section: sec.Title, Kind: SectionAdded, Added: sec.Content})
+			continue
+		}
+
+		// lines are compared as a multiset, moving a line is no change and
+		// blank lines are layout
+		remaining := make(map[string]int, len(before.Content))
+		for _, line := range before.Content {
+			if strings.TrimSpace(line) != "" {
+				remaining[line]++
+			}
+		}
+		var added []string
+		for _, line := range sec.Content {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			if remaining[line] > 0 {
+				remaining[line]--
+				continue
+			}
+			added = append(added, line)
+		}
+		removed := 0
+		for _, n := range remaining {
+			removed += n
+		}
+		if len(added) > 0 || removed > 0 {
+			changes = append(changes, SectionChange{Section: sec.Title, Kind: SectionChanged, Added: added, Removed: removed})
+		}
+	}
+
+	for _, sec := range old.Sections {
+		if !seen[sec.Title] {
+			changes = append(changes, SectionChange{Section: sec.Title, Kind: SectionRemoved, Removed: len(sec.Content)})
+		}
+	}
+	return changes
+}
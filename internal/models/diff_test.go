@@ -0,0 +1,73 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	sec := func(title string, lines ...string) Section {
+		return Section{Title: title, Content: lines}
+	}
+	tests := []struct {
+		name string
+		old  []Section
+		new  []Section
+		want []SectionChange
+	}{
+		{"unchanged", []Section{sec("A", "a", "b")}, []Section{sec("A", "a", "b")}, nil},
+		{"lines moved", []Section{sec("A", "a", "b")}, []Section{sec("A", "b", "a")}, nil},
+		{"blank lines", []Section{sec("A", "a", "", "b")}, []Section{sec("A", "a", "b", "  ")}, nil},
+		{"sections reordered", []Section{sec("A", "a"), sec("B", "b")}, []Section{sec("B", "b"), sec("A", "a")}, nil},
+		{
+			"line added",
+			[]Section{sec("A", "a")},
+			[]Section{sec("A", "a", "b")},
+			[]SectionChange{{Section: "A", Kind: SectionChanged, Added: []string{"b"}}},
+		},
+		{
+			"line edited",
+			[]Section{sec("A", "a", "b")},
+			[]Section{sec("A", "a", "c")},
+			[]SectionChange{{Section: "A", Kind: SectionChanged, Added: []string{"c"}, Removed: 1}},
+		},
+		{
+			"duplicate line added",
+			[]Section{sec("A", "a")},
+			[]Section{sec("A", "a", "a")},
+			[]SectionChange{{Section: "A", Kind: SectionChanged, Added: []string{"a"}}},
+		},
+		{
+			"line removed",
+			[]Section{sec("A", "a", "b")},
+			[]Section{sec("A", "a")},
+			[]SectionChange{{Section: "A", Kind: SectionChanged, Removed: 1}},
+		},
+		{
+			"section added and removed",
+			[]Section{sec("A", "a"), sec("Old", "x", "y")},
+			[]Section{sec("New", "n"), sec("A", "a")},
+			[]SectionChange{
+				{Section: "New", Kind: SectionAdded, Added: []string{"n"}},
+				{Section: "Old", Kind: SectionRemoved, Removed: 2},
+			},
+		},
+		{
+			"renamed",
+			[]Section{sec("A", "a")},
+			[]Section{sec("B", "a")},
+			[]SectionChange{
+				{Section: "B", Kind: SectionAdded, Added: []string{"a"}},
+				{Section: "A", Kind: SectionRemoved, Removed: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Portfolio{Sections: tt.new}.Diff(Portfolio{Sections: tt.old})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
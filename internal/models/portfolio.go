@@ -1,18 +1,30 @@
 package models
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
 type Portfolio struct {
-	Title    string    // name or title 
-	Sections []Section // content sections
-	Theme    Theme     // color scheme
+	Title    string    `json:"title"`    // name or title
+	Sections []Section `json:"sections"` // content sections
+	Theme    Theme     `json:"theme"`    // color scheme
+
+	// content variants by SSH user name, see ForAudience
+	Audiences       []Audience `json:"audiences,omitempty"`
+	DefaultAudience string     `json:"default_audience,omitempty"` // audience of unknown user names, none when empty
 }
 
 type Theme struct {
-	Primary   string // Primary color (for highlights, borders)
-	Accent    string // Accent color (for selected items)
-	Text      string // Main text color
-	Subtle    string // Subtle text color (for secondary information)
-	Links     string // Color for links
-	Selection string // Color for selected links
+	Primary   string `json:"primary"`   // Primary color (for highlights, borders)
+	Accent    string `json:"accent"`    // Accent color (for selected items)
+	Text      string `json:"text"`      // Main text color
+	Subtle    string `json:"subtle"`    // Subtle text color (for secondary information)
+	Links     string `json:"links"`     // Color for links
+	Selection string `json:"selection"` // Color for selected links
 }
 
 func DefaultPortfolio() Portfolio {
@@ -92,3 +104,130 @@ func DefaultPortfolio() Portfolio {
 func GetPortfolio() Portfolio {
 	return DefaultPortfolio()
 }
+
+// LoadPortfolio reads a JSON content file. An empty path returns the
+// built-in portfolio; theme colors missing from the file fall back to the
+// default theme.
+func LoadPortfolio(path string) (Portfolio, error) {
+	if path == "" {
+		return GetPortfolio(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Portfolio{}, fmt.Errorf("failed to read content file: %w", err)
+	}
+
+	portfolio := Portfolio{Theme: DefaultPortfolio().Theme}
+	if err := json.Unmarshal(data, &portfolio); err != nil {
+		return Portfolio{}, fmt.Errorf("failed to parse content file %s: %w", path, err)
+	}
+
+	if err := portfolio.Validate(); err != nil {
+		return Portfolio{}, fmt.Errorf("invalid content file %s: %w", path, err)
+	}
+
+	return portfolio, nil
+}
+
+// SavePortfolio writes a content file atomically: the JSON goes to a
+// temporary file in the same directory which then replaces path, so
+// readers see either the old or the new content.
+func SavePortfolio(path string, p Portfolio) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// keep the permissions of the file being replaced
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save content file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(append(data, '\n'))
+	if err == nil {
+		err = tmp.Chmod(mode)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save content file: %w", err)
+	}
+	return nil
+}
+
+// Validate reports content the TUI cannot render
+func (p Portfolio) Validate() error {
+	if len(p.Sections) == 0 {
+		return errors.New("portfolio has no sections")
+	}
+	visible := 0
+	for i, sec := range p.Sections {
+		if sec.Title == "" {
+			return fmt.Errorf("section %d has no title", i+1)
+		}
+		if !sec.Hidden && !sec.Private {
+			visible++
+		}
+	}
+	if visible == 0 {
+		return errors.New("portfolio has no visible sections")
+	}
+	if err := p.validateTemplates(); err != nil {
+		return err
+	}
+	return p.validateAudiences()
+}
+
+// Visible returns the portfolio without its hidden sections
+func (p Portfolio) Visible() Portfolio {
+	sections := make([]Section, 0, len(p.Sections))
+	for _, sec := range p.Sections {
+		if !sec.Hidden {
+			sections = append(sections, sec)
+		}
+	}
+	p.Sections = sections
+	return p
+}
+
+// Public returns the portfolio without its private sections
+func (p Portfolio) Public() Portfolio {
+	sections := make([]Section, 0, len(p.Sections))
+	for _, sec := range p.Sections {
+		if !sec.Private {
+			sections = append(sections, sec)
+		}
+	}
+	p.Sections = sections
+	return p
+}
+
+// Clone returns a copy that can be edited without affecting p
+func (p Portfolio) Clone() Portfolio {
+	sections := make([]Section, len(p.Sections))
+	for i, sec := range p.Sections {
+		sec.Content = append([]string(nil), sec.Content...)
+		sec.Details = append([]string(nil), sec.Details...)
+		sections[i] = sec
+	}
+	p.Sections = sections
+	return p
+}
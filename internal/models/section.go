@@ -1,6 +1,11 @@
 package models
 
 type Section struct {
-	Title   string
-	Content []string
+	Title     string   `json:"title"`
+	Content   []string `json:"content"`
+	Details   []string `json:"details,omitempty"`   // more lines, shown to audiences that expand the section
+	Hidden    bool     `json:"hidden,omitempty"`    // only shown to owners
+	Private   bool     `json:"private,omitempty"`   // only shown to owners, allowed keys and invited visitors
+	Audiences []string `json:"audiences,omitempty"` // only shown to these audiences
+	Exclude   []string `json:"exclude,omitempty"`   // shown to every audience but these
 }
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData is what template expressions in content lines can use, e.g.
+// "hi {{.Visitor.User}}, {{.Greeting}}" or "{{years \"2017-09\"}} years of go"
+type TemplateData struct {
+	Visitor  VisitorInfo
+	Server   ServerInfo
+	Now      time.Time      // in the visitor's TZ, the server's time zone without one
+	Greeting string         // "Good morning", "Good afternoon", ... at Now
+	Data     map[string]any // values from the template data file
+}
+
+// VisitorInfo describes the session a line is rendered for
+type VisitorInfo struct {
+	User      string
+	Transport string // "ssh", "web" or "telnet"
+	Term      string
+	Width     int
+	Height    int
+}
+
+// ServerInfo describes the server a line is rendered on
+type ServerInfo struct {
+	Uptime   string // e.g. "3 days"
+	Online   int    // live sessions
+	Visitors int    // keys seen so far, 0 when visitors are not tracked
+}
+
+// missingValue replaces an expression that cannot be rendered
+const missingValue = "?"
+
+// action matches one template expression in a line
+var action = regexp.MustCompile(`{{.*?}}`)
+
+// Greeting returns the greeting for the time of day at t
+func Greeting(t time.Time) string {
+	switch h := t.Hour(); {
+	case h >= 5 && h < 12:
+		return "Good morning"
+	case h >= 12 && h < 18:
+		return "Good afternoon"
+	case h >= 18 && h < 22:
+		return "Good evening"
+	default:
+		return "Good night"
+	}
+}
+
+// Renderer evaluates the template expressions in content lines for one
+// session
+type Renderer struct {
+	data  TemplateData
+	funcs template.FuncMap
+}
+
+func NewRenderer(data TemplateData) *Renderer {
+	return &Renderer{data: data, funcs: templateFuncs(data.Now)}
+}
+
+// templateFuncs are the functions expressions can call, dates counted up
+// to now
+func templateFuncs(now time.Time) template.FuncMap {
+	years := func(date string) (int, error) {
+		return yearsSince(date, now)
+	}
+	return template.FuncMap{
+		"years": years, // {{years "2017-09"}}, whole years since a date
+		"age":   years, // {{age "1998-04-12"}}, the same for birthdays
+	}
+}
+
+// yearsSince counts the whole years from a date written as 2006-01-02,
+// 2006-01 or 2006 to now
+func yearsSince(date string, now time.Time) (int, error) {
+	for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+		t, err := time.ParseInLocation(layout, date, now.Location())
+		if err != nil {
+			continue
+		}
+		years := now.Year() - t.Year()
+		if now.Month() < t.Month() || now.Month() == t.Month() && now.Day() < t.Day() {
+			years--
+		}
+		return years, nil
+	}
+	return 0, fmt.Errorf("invalid date %q, use YYYY-MM-DD", date)
+}
+
+// Line renders the template expressions in a line. When the line cannot be
+// rendered as a whole, e.g. because a value is missing, each expression is
+// rendered on its own and the ones that fail are shown as "?".
+func (r *Renderer) Line(line string) string {
+	if !strings.Contains(line, "{{") {
+		return line
+	}
+	if out, err := r.execute(line); err == nil {
+		return out
+	}
+	return action.ReplaceAllStringFunc(line, func(expr string) string {
+		out, err := r.execute(expr)
+		if err != nil {
+			return missingValue
+		}
+		return out
+	})
+}
+
+func (r *Renderer) execute(text string) (string, error) {
+	tmpl, err := template.New("line").Funcs(r.funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, r.data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Render returns the portfolio with every line passed through render,
+// usually a Renderer's Line
+func (p Portfolio) Render(render func(line string) string) Portfolio {
+	p = p.Clone()
+	for i := range p.Sections {
+		sec := &p.Sections[i]
+		for j, line := range sec.Content {
+			sec.Content[j] = render(line)
+		}
+		for j, line := range sec.Details {
+			sec.Details[j] = render(line)
+		}
+	}
+	return p
+}
+
+// validateTemplates checks that the template expressions in every line
+// parse, values are only known when a line is rendered
+func (p Portfolio) validateTemplates() error {
+	funcs := templateFuncs(time.Now())
+	for _, sec := range p.Sections {
+		lines := append(append([]string(nil), sec.Content...), sec.Details...)
+		for _, line := range lines {
+			if !strings.Contains(line, "{{") {
+				continue
+			}
+			if _, err := template.New("line").Funcs(funcs).Parse(line); err != nil {
+				return fmt.Errorf("section %q line %q: %w", sec.Title, line, err)
+			}
+		}
+	}
+	return nil
+}
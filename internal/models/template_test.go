@@ -0,0 +1,103 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRendererLine(t *testing.T) {
+	now := time.Date(2026, 3, 14, 9, 30, 0, 0, time.UTC)
+	r := NewRenderer(TemplateData{
+		Visitor:  VisitorInfo{User: "alice", Transport: "ssh", Width: 120},
+		Server:   ServerInfo{Online: 3},
+		Now:      now,
+		Greeting: Greeting(now),
+		Data:     map[string]any{"city": "Istanbul"},
+	})
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"plain line", "plain line"},
+		{"{ not a template }", "{ not a template }"},
+		{"hi {{.Visitor.User}}", "hi alice"},
+		{"{{.Greeting}}, {{.Server.Online}} online", "Good morning, 3 online"},
+		{"based in {{.Data.city}}", "based in Istanbul"},
+		{"{{years \"2017-09\"}} years of go", "8 years of go"},
+		{"{{age \"2000-03-15\"}}", "25"},
+		{"{{age \"2000-03-14\"}}", "26"},
+		{"{{years \"2020\"}}", "6"},
+		{"{{if gt .Visitor.Width 100}}wide{{else}}narrow{{end}}", "wide"},
+		// whatever cannot be rendered shows as ?, the rest of the line stays
+		{"hi {{.Visitor.User}} from {{.Data.nowhere}}", "hi alice from ?"},
+		{"{{.Nope}} and {{.Visitor.Transport}}", "? and ssh"},
+		{"{{years \"soon\"}} years", "? years"},
+		{"{{if .Visitor.User}}open", "?open"},
+		{"{{", "{{"},
+	}
+	for _, tt := range tests {
+		if got := r.Line(tt.line); got != tt.want {
+			t.Errorf("Line(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestGreeting(t *testing.T) {
+	tests := []struct {
+		hour int
+		want string
+	}{
+		{4, "Good night"},
+		{5, "Good morning"},
+		{11, "Good morning"},
+		{12, "Good afternoon"},
+		{17, "Good afternoon"},
+		{18, "Good evening"},
+		{21, "Good evening"},
+		{22, "Good night"},
+		{0, "Good night"},
+	}
+	for _, tt := range tests {
+		if got := Greeting(time.Date(2026, 1, 1, tt.hour, 0, 0, 0, time.UTC)); got != tt.want {
+			t.Errorf("Greeting at %d:00 = %q, want %q", tt.hour, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	tests := []struct {
+		line string
+		err  bool
+	}{
+		{"hi {{.Visitor.User}}", false},
+		{"{{.Data.anything}}", false}, // values are only known when rendering
+		{"{{years \"2017\"}}", false},
+		{"{{if .Visitor.User}}open", true},
+		{"{{nosuchfunc 1}}", true},
+		{"{{.Visitor.User", true},
+	}
+	for _, tt := range tests {
+		p := Portfolio{Sections: []Section{{Title: "A", Details: []string{tt.line}}}}
+		err := p.validateTemplates()
+		if (err != nil) != tt.err {
+			t.Errorf("validateTemplates(%q) = %v, want error %v", tt.line, err, tt.err)
+		}
+		if err != nil && !strings.Contains(err.Error(), `section "A"`) {
+			t.Errorf("error %q does not name the section", err)
+		}
+	}
+}
+
+// Render must not change the content it was called on
+func TestRenderKeepsContent(t *testing.T) {
+	p := Portfolio{Sections: []Section{{Title: "A", Content: []string{"{{.Greeting}}"}, Details: []string{"x"}}}}
+	got := p.Render(strings.ToUpper)
+	if got.Sections[0].Content[0] != "{{.GREETING}}" || got.Sections[0].Details[0] != "X" {
+		t.Errorf("Render = %+v", got.Sections[0])
+	}
+	if p.Sections[0].Content[0] != "{{.Greeting}}" || p.Sections[0].Details[0] != "x" {
+		t.Error("Render changed the portfolio")
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+// ThemePreset is a named color scheme owners can switch to in admin mode
+type ThemePreset struct {
+	Name  string
+	Theme Theme
+}
+
+// ThemePresets lists the color schemes offered in admin mode, the default
+// theme first
+var ThemePresets = []ThemePreset{
+	{"default", DefaultPortfolio().Theme},
+	{"gruvbox", Theme{
+		Primary:   "#d79921",
+		Accent:    "#fe8019",
+		Text:      "#ebdbb2",
+		Subtle:    "#665c54",
+		Links:     "#83a598",
+		Selection: "#d3869b",
+	}},
+	{"nord", Theme{
+		Primary:   "#81a1c1",
+		Accent:    "#88c0d0",
+		Text:      "#d8dee9",
+		Subtle:    "#4c566a",
+		Links:     "#8fbcbb",
+		Selection: "#b48ead",
+	}},
+	{"solarized", Theme{
+		Primary:   "#268bd2",
+		Accent:    "#cb4b16",
+		Text:      "#93a1a1",
+		Subtle:    "#586e75",
+		Links:     "#2aa198",
+		Selection: "#d33682",
+	}},
+	{"mono", Theme{
+		Primary:   "#bcbcbc",
+		Accent:    "#ffffff",
+		Text:      "#d0d0d0",
+		Subtle:    "#585858",
+		Links:     "#eeeeee",
+		Selection: "#ffffff",
+	}},
+}
@@ -0,0 +1,125 @@
+// Package recording writes and plays back terminal sessions in the
+// asciicast v2 format used by asciinema: a JSON header line followed by
+// one JSON array per event, [seconds, type, data].
+package recording
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Extension is the file name extension of recordings
+const Extension = ".cast"
+
+// Header is the first line of a recording
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"` // start, in Unix seconds
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// asciicast v2 event types
+const (
+	eventOutput = "o"
+	eventResize = "r"
+)
+
+// Writer records what a terminal was shown. It is an io.Writer for the
+// output; recording errors are logged once and never reach the caller, so
+// a full disk does not end a session.
+type Writer struct {
+	mu      sync.Mutex
+	file    *os.File
+	start   time.Time
+	pending []byte // start of a UTF-8 sequence split across writes
+	err     error
+}
+
+// Create starts a recording at path, creating its directory. Recordings
+// are readable by the server user only.
+func Create(path string, h Header) (*Writer, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	h.Version = 2
+	line, err := json.Marshal(h)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &Writer{file: file, start: time.Now()}, nil
+}
+
+// Write records output
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data := append(w.pending, p...)
+	// hold back a rune cut in two, JSON would replace its halves
+	cut := len(data)
+	for i := 1; i <= utf8.UTFMax && i <= len(data); i++ {
+		if start := len(data) - i; utf8.RuneStart(data[start]) {
+			if !utf8.FullRune(data[start:]) {
+				cut = start
+			}
+			break
+		}
+	}
+	w.pending = append([]byte(nil), data[cut:]...)
+
+	if cut > 0 {
+		w.event(eventOutput, string(data[:cut]))
+	}
+	return len(p), nil
+}
+
+// Resize records a change of the terminal size
+func (w *Writer) Resize(width, height int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.event(eventResize, fmt.Sprintf("%dx%d", width, height))
+}
+
+func (w *Writer) event(kind, data string) {
+	if w.err != nil {
+		return
+	}
+	seconds := math.Round(time.Since(w.start).Seconds()*1e6) / 1e6
+	line, err := json.Marshal([]any{seconds, kind, data})
+	if err == nil {
+		_, err = w.file.Write(append(line, '\n'))
+	}
+	w.err = err
+}
+
+// Close ends the recording, returning the first error it ran into
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.pending) > 0 {
+		w.event(eventOutput, string(w.pending))
+	}
+	if err := w.file.Close(); w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
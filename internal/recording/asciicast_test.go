@@ -0,0 +1,138 @@
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unicode/utf8"
+)
+
+// events returns the type and data of every event in the recording at path
+func events(t *testing.T, path string) [][2]string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	var list [][2]string
+	for scanner.Scan() {
+		var event []any
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatal(err)
+		}
+		list = append(list, [2]string{event[1].(string), event[2].(string)})
+	}
+	return list
+}
+
+func TestWriterSplitsRunes(t *testing.T) {
+	euro := []byte("€")  // 3 bytes
+	emoji := []byte("🦫") // 4 bytes
+
+	tests := []struct {
+		name   string
+		writes [][]byte
+		want   []string // data of the output events
+	}{
+		{"ascii", [][]byte{[]byte("ab"), []byte("c")}, []string{"ab", "c"}},
+		{"whole runes", [][]byte{[]byte("€1"), []byte("🦫")}, []string{"€1", "🦫"}},
+		{"rune cut after one byte", [][]byte{append([]byte("a"), euro[:1]...), euro[1:]}, []string{"a", "€"}},
+		{"rune cut after two bytes", [][]byte{euro[:2], append(euro[2:], 'b')}, []string{"€b"}},
+		{"rune over three writes", [][]byte{emoji[:1], emoji[1:3], emoji[3:]}, []string{"🦫"}},
+		{"cut rune at close", [][]byte{[]byte("x"), emoji[:2]}, []string{"x", "��"}},
+		{"invalid byte passes", [][]byte{{0xff, 'a'}}, []string{"�a"}},
+		{"lone continuation byte", [][]byte{{'a', 0x80}}, []string{"a�"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "session"+Extension)
+			w, err := Create(path, Header{Width: 80, Height: 24})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, p := range tt.writes {
+				if n, err := w.Write(p); n != len(p) || err != nil {
+					t.Fatalf("Write = %d, %v", n, err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, e := range events(t, path) {
+				if e[0] != eventOutput {
+					t.Errorf("unexpected %q event", e[0])
+				}
+				if !utf8.ValidString(e[1]) {
+					t.Errorf("event data %q is not UTF-8", e[1])
+				}
+				got = append(got, e[1])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlayback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session"+Extension)
+	w, err := Create(path, Header{Width: 100, Height: 30, Title: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("hello "))
+	w.Resize(120, 40)
+	w.Write([]byte("wörld"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Create(path, Header{}); err == nil {
+		t.Error("Create replaced an existing recording")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	p, err := NewPlayer(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Header.Version != 2 || p.Header.Width != 100 || p.Header.Title != "test" {
+		t.Errorf("header = %+v", p.Header)
+	}
+	var out bytes.Buffer
+	if err := p.Play(context.Background(), &out, 1000, 0); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "hello wörld" {
+		t.Errorf("played %q, want %q", out.String(), "hello wörld")
+	}
+}
+
+func TestNewPlayerErrors(t *testing.T) {
+	tests := []struct {
+		name, data string
+	}{
+		{"empty", ""},
+		{"not json", "hello\n"},
+		{"version 1", `{"version": 1, "width": 80, "height": 24}` + "\n"},
+	}
+	for _, tt := range tests {
+		if _, err := NewPlayer(bytes.NewReader([]byte(tt.data))); err == nil {
+			t.Errorf("%s: NewPlayer succeeded", tt.name)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package recording
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Player reads a recording event by event
+type Player struct {
+	Header Header
+	dec    *json.Decoder
+}
+
+// NewPlayer reads the header of a recording
+func NewPlayer(r io.Reader) (*Player, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var h Header
+	if err := dec.Decode(&h); err != nil {
+		return nil, fmt.Errorf("not an asciicast file: %w", err)
+	}
+	if h.Version != 2 {
+		return nil, fmt.Errorf("unsupported asciicast version %d", h.Version)
+	}
+	return &Player{Header: h, dec: dec}, nil
+}
+
+// Play writes the recorded output to w at the pace it was recorded, speed
+// times faster. Pauses longer than maxIdle are cut short to it, 0 keeps
+// them. Resize events are skipped, a terminal cannot be resized from here.
+func (p *Player) Play(ctx context.Context, w io.Writer, speed float64, maxIdle time.Duration) error {
+	if speed <= 0 {
+		speed = 1
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	var last float64
+	for n := 2; ; n++ {
+		var raw []json.RawMessage
+		if err := p.dec.Decode(&raw); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("event %d: %w", n, err)
+		}
+
+		var at float64
+		var kind, data string
+		if len(raw) != 3 || json.Unmarshal(raw[0], &at) != nil ||
+			json.Unmarshal(raw[1], &kind) != nil || json.Unmarshal(raw[2], &data) != nil {
+			return fmt.Errorf("event %d: not a [time, type, data] event", n)
+		}
+
+		wait := time.Duration((at - last) * float64(time.Second))
+		last = at
+		if maxIdle > 0 && wait > maxIdle {
+			wait = maxIdle
+		}
+		if wait = time.Duration(float64(wait) / speed); wait > 0 {
+			timer.Reset(wait)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		if kind == eventOutput {
+			if _, err := io.WriteString(w, data); err != nil {
+				return err
+			}
+		}
+	}
+}
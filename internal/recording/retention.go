@@ -0,0 +1,56 @@
+package recording
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Prune deletes the recordings in dir older than maxAge and, of the rest,
+// all but the newest maxFiles. A zero limit is not applied. It returns how
+// many were deleted.
+func Prune(dir string, maxAge time.Duration, maxFiles int) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	type recording struct {
+		path    string
+		modTime time.Time
+	}
+	var recordings []recording
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), Extension) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		recordings = append(recordings, recording{filepath.Join(dir, e.Name()), info.ModTime()})
+	}
+	// newest first
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].modTime.After(recordings[j].modTime)
+	})
+
+	deleted := 0
+	for i, r := range recordings {
+		tooOld := maxAge > 0 && time.Since(r.modTime) > maxAge
+		tooMany := maxFiles > 0 && i >= maxFiles
+		if !tooOld && !tooMany {
+			continue
+		}
+		if err := os.Remove(r.path); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
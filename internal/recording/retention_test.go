@@ -0,0 +1,65 @@
+package recording
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestPrune(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxAge   time.Duration
+		maxFiles int
+		kept     []string
+	}{
+		{"no limits", 0, 0, []string{"a.cast", "b.cast", "c.cast", "d.cast", "notes.txt"}},
+		{"by age", 36 * time.Hour, 0, []string{"a.cast", "b.cast", "notes.txt"}},
+		{"by count", 0, 3, []string{"a.cast", "b.cast", "c.cast", "notes.txt"}},
+		{"both", 36 * time.Hour, 1, []string{"a.cast", "notes.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			// a.cast is the newest, each one a day older than the last
+			for i, name := range []string{"a.cast", "b.cast", "c.cast", "d.cast", "notes.txt"} {
+				path := filepath.Join(dir, name)
+				if err := os.WriteFile(path, nil, 0600); err != nil {
+					t.Fatal(err)
+				}
+				modTime := time.Now().Add(-time.Duration(i) * 24 * time.Hour)
+				if err := os.Chtimes(path, modTime, modTime); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			deleted, err := Prune(dir, tt.maxAge, tt.maxFiles)
+			if err != nil {
+				t.Fatal(err)
+			}
+			entries, _ := os.ReadDir(dir)
+			var kept []string
+			for _, e := range entries {
+				kept = append(kept, e.Name())
+			}
+			sort.Strings(kept)
+			if len(kept) != len(tt.kept) || deleted != 5-len(tt.kept) {
+				t.Fatalf("kept %v, deleted %d, want %v", kept, deleted, tt.kept)
+			}
+			for i := range kept {
+				if kept[i] != tt.kept[i] {
+					t.Errorf("kept %v, want %v", kept, tt.kept)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestPruneMissingDir(t *testing.T) {
+	if n, err := Prune(filepath.Join(t.TempDir(), "missing"), time.Hour, 1); n != 0 || err != nil {
+		t.Errorf("Prune = %d, %v, want 0, nil", n, err)
+	}
+}
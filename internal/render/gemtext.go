@@ -0,0 +1,53 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/tui"
+)
+
+// GemtextIndex renders the capsule home page, linking to one page per
+// section at /<anchor>
+func GemtextIndex(p models.Portfolio) string {
+	var b strings.Builder
+
+	b.WriteString("# " + p.Title + "\n\n")
+	for _, sec := range p.Sections {
+		b.WriteString("=> /" + Anchor(sec.Title) + " " + sec.Title + "\n")
+	}
+	return b.String()
+}
+
+// GemtextSection renders one section page. Every URL in the content gets
+// a link line of its own after the text.
+func GemtextSection(sec models.Section) string {
+	var b strings.Builder
+
+	b.WriteString("# " + sec.Title + "\n\n")
+	for _, line := range sec.Content {
+		for _, part := range strings.Split(line, "\n") {
+			b.WriteString(gemtextLine(part) + "\n")
+		}
+	}
+
+	if links := tui.FindLinks(sec.Content); len(links) > 0 {
+		b.WriteString("\n## Links\n\n")
+		for _, link := range links {
+			b.WriteString("=> " + link + "\n")
+		}
+	}
+
+	b.WriteString("\n=> / Back\n")
+	return b.String()
+}
+
+// gemtextLine keeps content lines from being read as gemtext markup
+func gemtextLine(line string) string {
+	for _, prefix := range []string{"=>", "#", "*", ">", "```"} {
+		if strings.HasPrefix(line, prefix) {
+			return " " + line
+		}
+	}
+	return line
+}
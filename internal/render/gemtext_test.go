@@ -0,0 +1,48 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+func TestGemtextLine(t *testing.T) {
+	tests := map[string]string{
+		"plain":            "plain",
+		"=> /link":         " => /link",
+		"# heading":        " # heading",
+		"* item":           " * item",
+		"> quote":          " > quote",
+		"```":              " ```",
+		"a => b":           "a => b",
+		"":                 "",
+		"https://x.test/a": "https://x.test/a",
+	}
+	for line, want := range tests {
+		if got := gemtextLine(line); got != want {
+			t.Errorf("gemtextLine(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+func TestGemtext(t *testing.T) {
+	p := models.Portfolio{
+		Title: "Jane",
+		Sections: []models.Section{
+			{Title: "About Me", Content: []string{"# not a heading", "two\nlines"}},
+			{Title: "Links", Content: []string{"blog https://example.com/blog"}},
+		},
+	}
+	tests := []struct {
+		name, got, want string
+	}{
+		{"index", GemtextIndex(p), "# Jane\n\n=> /about-me About Me\n=> /links Links\n"},
+		{"section", GemtextSection(p.Sections[0]), "# About Me\n\n # not a heading\ntwo\nlines\n\n=> / Back\n"},
+		{"links", GemtextSection(p.Sections[1]), "# Links\n\nblog https://example.com/blog\n\n## Links\n\n=> https://example.com/blog\n\n=> / Back\n"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s =\n%q\nwant\n%q", tt.name, tt.got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/tui"
+)
+
+// GopherMenu renders the portfolio as a gopher menu: an info header with
+// the title, a text item per section at /<anchor> and a URL item for every
+// link. host and port are where clients fetch the items from.
+func GopherMenu(p models.Portfolio, host, port string) string {
+	var b strings.Builder
+
+	item := func(kind, display, selector string) {
+		b.WriteString(kind + gopherField(display) + "\t" + selector + "\t" + host + "\t" + port + "\r\n")
+	}
+	info := func(text string) {
+		// info lines point nowhere, the selector and host are placeholders
+		b.WriteString("i" + gopherField(text) + "\t\terror.host\t1\r\n")
+	}
+
+	info(p.Title)
+	info(strings.Repeat("=", len([]rune(p.Title))))
+	info("")
+	for _, sec := range p.Sections {
+		item("0", sec.Title, "/"+Anchor(sec.Title))
+	}
+
+	for _, sec := range p.Sections {
+		links := tui.FindLinks(sec.Content)
+		if len(links) == 0 {
+			continue
+		}
+		info("")
+		info(sec.Title + " links")
+		for _, link := range links {
+			item("h", link, "URL:"+link)
+		}
+	}
+
+	b.WriteString(".\r\n")
+	return b.String()
+}
+
+// GopherText wraps a text document for a gopher text item, doubling lines
+// that start with the terminating dot
+func GopherText(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		if strings.HasPrefix(line, ".") {
+			line = "." + line
+		}
+		b.WriteString(line + "\r\n")
+	}
+	b.WriteString(".\r\n")
+	return b.String()
+}
+
+// gopherField keeps tabs and line breaks out of a menu field
+func gopherField(s string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,53 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+func TestGopherText(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{"lines", "one\ntwo\n", "one\r\ntwo\r\n.\r\n"},
+		{"no trailing newline", "one", "one\r\n.\r\n"},
+		{"lone dot", "one\n.\ntwo", "one\r\n..\r\ntwo\r\n.\r\n"},
+		{"leading dot", ".hidden\n..two", "..hidden\r\n...two\r\n.\r\n"},
+		{"indented dot", "  .ok", "  .ok\r\n.\r\n"},
+		{"empty", "", "\r\n.\r\n"},
+	}
+	for _, tt := range tests {
+		if got := GopherText(tt.text); got != tt.want {
+			t.Errorf("%s: GopherText(%q) = %q, want %q", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestGopherMenu(t *testing.T) {
+	p := models.Portfolio{
+		Title: "Jane",
+		Sections: []models.Section{
+			{Title: "About Me", Content: []string{"Hi"}},
+			{Title: "Links\tand\ntabs", Content: []string{"https://example.com and https://b.example"}},
+		},
+	}
+	got := GopherMenu(p, "gopher.example", "70")
+	want := strings.Join([]string{
+		"iJane\t\terror.host\t1",
+		"i====\t\terror.host\t1",
+		"i\t\terror.host\t1",
+		"0About Me\t/about-me\tgopher.example\t70",
+		"0Links and tabs\t/linksandtabs\tgopher.example\t70",
+		"i\t\terror.host\t1",
+		"iLinks and tabs links\t\terror.host\t1",
+		"hhttps://example.com\tURL:https://example.com\tgopher.example\t70",
+		"hhttps://b.example\tURL:https://b.example\tgopher.example\t70",
+		".",
+		"",
+	}, "\r\n")
+	if got != want {
+		t.Errorf("GopherMenu =\n%q\nwant\n%q", got, want)
+	}
+}
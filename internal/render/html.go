@@ -0,0 +1,96 @@
+package render
+
+import (
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/tui"
+)
+
+var htmlPage = template.Must(template.New("page").Funcs(template.FuncMap{
+	"anchor":  Anchor,
+	"linkify": linkify,
+}).Parse(`<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Portfolio.Title}}</title>
+<style>
+body { background: #1e222a; color: {{.Portfolio.Theme.Text}}; font-family: ui-monospace, monospace; line-height: 1.5; margin: 0; }
+main { max-width: 48rem; margin: 2rem auto; padding: 1rem 2rem; border: 1px solid {{.Portfolio.Theme.Primary}}; }
+h1 { color: {{.Portfolio.Theme.Primary}}; font-style: italic; text-align: center; border-bottom: 2px solid {{.Portfolio.Theme.Primary}}; padding-bottom: .5rem; }
+h1 span { color: {{.Portfolio.Theme.Accent}}; }
+nav ul { list-style: none; padding: 0; display: flex; flex-wrap: wrap; gap: 1rem; justify-content: center; }
+nav a { color: {{.Portfolio.Theme.Accent}}; text-transform: capitalize; }
+h2 { color: {{.Portfolio.Theme.Primary}}; text-transform: uppercase; border-bottom: 1px solid {{.Portfolio.Theme.Subtle}}; }
+p { margin: .25rem 0 .25rem 1rem; white-space: pre-wrap; }
+a { color: {{.Portfolio.Theme.Links}}; }
+a:hover, a:focus { color: {{.Portfolio.Theme.Selection}}; }
+footer { color: {{.Portfolio.Theme.Subtle}}; text-align: center; margin-top: 2rem; }
+</style>
+</head>
+<body>
+<main>
+<header>
+<h1><span>◇</span> {{.Portfolio.Title}} <span>◇</span></h1>
+<nav><ul>
+{{- range .Portfolio.Sections}}
+<li><a href="#{{anchor .Title}}">{{.Title}}</a></li>
+{{- end}}
+</ul></nav>
+</header>
+{{- range .Portfolio.Sections}}
+<section id="{{anchor .Title}}">
+<h2>{{.Title}}</h2>
+{{- range .Content}}
+<p>{{linkify .}}</p>
+{{- end}}
+</section>
+{{- end}}
+{{- if .SSHCommand}}
+<footer><p>also in your terminal: <code>{{.SSHCommand}}</code></p></footer>
+{{- end}}
+</main>
+</body>
+</html>
+`))
+
+// HTML writes the portfolio as a minimal semantic page colored with the
+// theme. sshCommand, when set, is shown as a hint in the footer.
+func HTML(w io.Writer, p models.Portfolio, sshCommand string) error {
+	return htmlPage.Execute(w, struct {
+		Portfolio  models.Portfolio
+		SSHCommand string
+	}{p, sshCommand})
+}
+
+// Anchor turns a section title into a fragment or path name
+func Anchor(title string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '_':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// linkify escapes a content line and wraps its URLs in anchors
+func linkify(line string) template.HTML {
+	var b strings.Builder
+	last := 0
+	for _, match := range tui.LinkPattern.FindAllStringIndex(line, -1) {
+		b.WriteString(template.HTMLEscapeString(line[last:match[0]]))
+		url := template.HTMLEscapeString(line[match[0]:match[1]])
+		b.WriteString(`<a href="` + url + `">` + url + `</a>`)
+		last = match[1]
+	}
+	b.WriteString(template.HTMLEscapeString(line[last:]))
+	return template.HTML(strings.TrimRight(b.String(), "\n"))
+}
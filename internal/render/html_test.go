@@ -0,0 +1,69 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+func TestLinkify(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"<b>bold</b> & co", "&lt;b&gt;bold&lt;/b&gt; &amp; co"},
+		{"see https://example.com", `see <a href="https://example.com">https://example.com</a>`},
+		{"http://a.test and https://b.test", `<a href="http://a.test">http://a.test</a> and <a href="https://b.test">https://b.test</a>`},
+		{`https://x.test/"onmouseover="alert(1)`, `<a href="https://x.test/&#34;onmouseover=&#34;alert(1)">https://x.test/&#34;onmouseover=&#34;alert(1)</a>`},
+		{"https://x.test/?a=1&b=<2>", `<a href="https://x.test/?a=1&amp;b=&lt;2&gt;">https://x.test/?a=1&amp;b=&lt;2&gt;</a>`},
+		{"javascript:alert(1)", "javascript:alert(1)"},
+		{"trailing newline\n", "trailing newline"},
+	}
+	for _, tt := range tests {
+		if got := string(linkify(tt.line)); got != tt.want {
+			t.Errorf("linkify(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestAnchor(t *testing.T) {
+	tests := map[string]string{
+		"About":             "about",
+		"Open Source":       "open-source",
+		"C++ & Go_projects": "c--go-projects",
+		"Über":              "ber",
+		"":                  "",
+	}
+	for title, want := range tests {
+		if got := Anchor(title); got != want {
+			t.Errorf("Anchor(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
+
+// Titles and content are escaped wherever the page shows them
+func TestHTMLEscapes(t *testing.T) {
+	p := models.Portfolio{
+		Title: "<script>alert('title')</script>",
+		Sections: []models.Section{
+			{Title: "<i>About</i>", Content: []string{"<img src=x onerror=alert(1)>"}},
+		},
+	}
+	var b strings.Builder
+	if err := HTML(&b, p, "ssh <host>"); err != nil {
+		t.Fatal(err)
+	}
+	page := b.String()
+	for _, raw := range []string{"<script>alert", "<i>About", "<img", "<host>"} {
+		if strings.Contains(page, raw) {
+			t.Errorf("page contains unescaped %q", raw)
+		}
+	}
+	for _, escaped := range []string{"&lt;script&gt;", "&lt;img src=x onerror=alert(1)&gt;"} {
+		if !strings.Contains(page, escaped) {
+			t.Errorf("page lacks escaped %q", escaped)
+		}
+	}
+}
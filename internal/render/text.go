@@ -0,0 +1,112 @@
+// Package render turns a Portfolio into static documents for the non-TUI
+// front ends: plain and ANSI text, HTML and the small-web protocols.
+package render
+
+import (
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/tui"
+)
+
+// Plain renders the whole portfolio as uncolored text
+func Plain(p models.Portfolio) string {
+	var b strings.Builder
+
+	b.WriteString(p.Title + "\n")
+	b.WriteString(strings.Repeat("=", len([]rune(p.Title))) + "\n")
+
+	for _, sec := range p.Sections {
+		b.WriteString("\n")
+		b.WriteString(PlainSection(sec))
+	}
+	return b.String()
+}
+
+// PlainSection renders one section as uncolored text
+func PlainSection(sec models.Section) string {
+	var b strings.Builder
+
+	title := strings.ToUpper(sec.Title)
+	b.WriteString(title + "\n")
+	b.WriteString(strings.Repeat("-", len([]rune(title))) + "\n")
+	for _, line := range sec.Content {
+		b.WriteString(indent(line) + "\n")
+	}
+	return b.String()
+}
+
+// FindSection looks a section up by its title or anchor name, ignoring case
+func FindSection(p models.Portfolio, name string) (models.Section, bool) {
+	for _, sec := range p.Sections {
+		if strings.EqualFold(sec.Title, name) || Anchor(sec.Title) == strings.ToLower(name) {
+			return sec, true
+		}
+	}
+	return models.Section{}, false
+}
+
+// ANSI renders the portfolio with true color escapes in the TUI theme, for
+// curl and other terminal HTTP clients
+func ANSI(p models.Portfolio) string {
+	r := lipgloss.NewRenderer(io.Discard)
+	r.SetColorProfile(termenv.TrueColor)
+
+	primary := lipgloss.Color(p.Theme.Primary)
+	accent := lipgloss.Color(p.Theme.Accent)
+	text := r.NewStyle().Foreground(lipgloss.Color(p.Theme.Text))
+	subtle := r.NewStyle().Foreground(lipgloss.Color(p.Theme.Subtle))
+	link := r.NewStyle().Foreground(lipgloss.Color(p.Theme.Links))
+	ornament := r.NewStyle().Foreground(accent)
+	header := r.NewStyle().Foreground(primary).Bold(true)
+
+	var b strings.Builder
+
+	title := r.NewStyle().Foreground(primary).Bold(true).Italic(true).Render(p.Title)
+	b.WriteString(ornament.Render("◇") + " " + title + " " + ornament.Render("◇") + "\n")
+	b.WriteString(r.NewStyle().Foreground(primary).Render(strings.Repeat("━", len([]rune(p.Title))+4)) + "\n")
+
+	for _, sec := range p.Sections {
+		b.WriteString("\n")
+		b.WriteString(header.Render("✦"+strings.ToUpper(sec.Title)+"✦") + "\n")
+		b.WriteString(subtle.Render(strings.Repeat("─", len([]rune(sec.Title))+2)) + "\n")
+
+		for _, line := range sec.Content {
+			// style each physical line so escapes never span a newline
+			for _, part := range strings.Split(line, "\n") {
+				b.WriteString(indent(styleLinks(part, text, link)) + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// styleLinks renders the text of a line with one style and its URLs with another
+func styleLinks(line string, text, link lipgloss.Style) string {
+	var b strings.Builder
+	last := 0
+	for _, match := range tui.LinkPattern.FindAllStringIndex(line, -1) {
+		if match[0] > last {
+			b.WriteString(text.Render(line[last:match[0]]))
+		}
+		b.WriteString(link.Render(line[match[0]:match[1]]))
+		last = match[1]
+	}
+	if last < len(line) {
+		b.WriteString(text.Render(line[last:]))
+	}
+	return b.String()
+}
+
+// indent matches the two space content indent of the TUI; blank lines and
+// embedded newlines are kept as they are
+func indent(line string) string {
+	if strings.TrimSpace(line) == "" {
+		return strings.TrimRight(line, " ")
+	}
+	return "  " + line
+}
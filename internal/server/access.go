@@ -0,0 +1,273 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// accessControl applies the allow/deny lists and temporary bans. Allowed
+// addresses are never denied or banned, so a broad deny entry combined
+// with a narrow allow entry gives an allow-only setup.
+type accessControl struct {
+	mu      sync.Mutex // guards every field, the config changes on reload
+	config  AccessConfig
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+	bans    map[string]Ban
+	strikes map[string][]time.Time
+}
+
+// Ban is a temporary block on one address
+type Ban struct {
+	IP     string    `json:"ip"`
+	Reason string    `json:"reason"`
+	Until  time.Time `json:"until"`
+}
+
+func newAccessControl(config AccessConfig) (*accessControl, error) {
+	a := &accessControl{
+		config:  AccessConfig{BanFile: config.BanFile},
+		bans:    make(map[string]Ban),
+		strikes: make(map[string][]time.Time),
+	}
+	if err := a.reconfigure(config); err != nil {
+		return nil, err
+	}
+	if err := a.load(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// reconfigure applies new lists and ban settings, keeping the current
+// bans. The ban file is only read on start.
+func (a *accessControl) reconfigure(config AccessConfig) error {
+	allow, err := parseNetworks(config.Allow)
+	if err != nil {
+		return fmt.Errorf("invalid allow list: %w", err)
+	}
+	deny, err := parseNetworks(config.Deny)
+	if err != nil {
+		return fmt.Errorf("invalid deny list: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	config.BanFile = a.config.BanFile
+	a.config = config
+	a.allow = allow
+	a.deny = deny
+	return nil
+}
+
+// parseNetworks accepts CIDRs and bare IPs
+func parseNetworks(entries []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an IP or CIDR", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// check reports whether ip may connect, with the reason when it may not
+func (a *accessControl) check(ip string) (bool, string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	parsed := net.ParseIP(ip)
+	if parsed != nil && containsIP(a.allow, parsed) {
+		return true, ""
+	}
+	if parsed != nil && containsIP(a.deny, parsed) {
+		return false, "denied"
+	}
+	if b, ok := a.bans[ip]; ok {
+		if time.Now().Before(b.Until) {
+			return false, "banned"
+		}
+		delete(a.bans, ip)
+		a.saveLocked()
+	}
+	return true, ""
+}
+
+// strike records suspicious behaviour and bans ip once it crosses the
+// configured threshold within the window
+func (a *accessControl) strike(ip, reason string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.config.BanThreshold <= 0 {
+		return
+	}
+	if parsed := net.ParseIP(ip); parsed != nil && containsIP(a.allow, parsed) {
+		return
+	}
+
+	now := time.Now()
+	cutoff := now.Add(-time.Duration(a.config.BanWindow))
+	recent := a.strikes[ip][:0]
+	for _, t := range a.strikes[ip] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	recent = append(recent, now)
+
+	if len(recent) < a.config.BanThreshold {
+		a.strikes[ip] = recent
+		return
+	}
+
+	delete(a.strikes, ip)
+	a.banLocked(ip, "auto: "+reason, time.Duration(a.config.BanDuration))
+}
+
+// ban blocks ip for duration
+func (a *accessControl) ban(ip, reason string, duration time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.banLocked(ip, reason, duration)
+}
+
+func (a *accessControl) banLocked(ip, reason string, duration time.Duration) {
+	until := time.Now().Add(duration)
+	a.bans[ip] = Ban{IP: ip, Reason: reason, Until: until}
+	a.saveLocked()
+	slog.Warn("banned", "ip", ip, "reason", reason, "until", until.Format(time.RFC3339))
+}
+
+// unban lifts a ban, reporting whether one existed
+func (a *accessControl) unban(ip string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.bans[ip]; !ok {
+		return false
+	}
+	delete(a.bans, ip)
+	delete(a.strikes, ip)
+	a.saveLocked()
+	slog.Info("unbanned", "ip", ip)
+	return true
+}
+
+// listBans returns the active bans, soonest expiry first
+func (a *accessControl) listBans() []Ban {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now()
+	var bans []Ban
+	for _, b := range a.bans {
+		if now.Before(b.Until) {
+			bans = append(bans, b)
+		}
+	}
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Until.Before(bans[j].Until) })
+	return bans
+}
+
+// load restores bans saved by a previous run
+func (a *accessControl) load() error {
+	if a.config.BanFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(a.config.BanFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read ban file: %w", err)
+	}
+
+	var bans []Ban
+	if err := json.Unmarshal(data, &bans); err != nil {
+		return fmt.Errorf("failed to parse ban file %s: %w", a.config.BanFile, err)
+	}
+	now := time.Now()
+	for _, b := range bans {
+		if now.Before(b.Until) {
+			a.bans[b.IP] = b
+		}
+	}
+	return nil
+}
+
+// saveLocked writes the ban list, replacing the file atomically
+func (a *accessControl) saveLocked() {
+	if a.config.BanFile == "" {
+		return
+	}
+
+	bans := make([]Ban, 0, len(a.bans))
+	for _, b := range a.bans {
+		bans = append(bans, b)
+	}
+	data, err := json.MarshalIndent(bans, "", "  ")
+	if err != nil {
+		slog.Error("failed to encode bans", "error", err)
+		return
+	}
+
+	if err := writeFileAtomic(a.config.BanFile, append(data, '\n'), 0600); err != nil {
+		slog.Error("failed to save bans", "file", a.config.BanFile, "error", err)
+	}
+}
+
+// writeFileAtomic writes to a temporary file next to path and renames it
+// into place, so readers never see a partial file
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseNetworks(t *testing.T) {
+	tests := []struct {
+		entry string
+		in    []string
+		out   []string
+		err   bool
+	}{
+		{"192.0.2.7", []string{"192.0.2.7"}, []string{"192.0.2.8", "::1"}, false},
+		{"192.0.2.0/24", []string{"192.0.2.1", "192.0.2.255"}, []string{"192.0.3.1"}, false},
+		{"2001:db8::/32", []string{"2001:db8::1", "2001:db8:ffff::1"}, []string{"2001:db9::1", "192.0.2.1"}, false},
+		{"2001:db8::7", []string{"2001:db8::7"}, []string{"2001:db8::8"}, false},
+		{"::ffff:192.0.2.7", []string{"192.0.2.7"}, nil, false},
+		{"0.0.0.0/0", []string{"198.51.100.1"}, nil, false},
+		{"example.com", nil, nil, true},
+		{"192.0.2.0/33", nil, nil, true},
+		{"192.0.2.300", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.entry, func(t *testing.T) {
+			nets, err := parseNetworks([]string{tt.entry})
+			if tt.err {
+				if err == nil {
+					t.Fatal("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, ip := range tt.in {
+				if !containsIP(nets, net.ParseIP(ip)) {
+					t.Errorf("%s not in %s", ip, tt.entry)
+				}
+			}
+			for _, ip := range tt.out {
+				if containsIP(nets, net.ParseIP(ip)) {
+					t.Errorf("%s in %s", ip, tt.entry)
+				}
+			}
+		})
+	}
+}
+
+func TestAccessCheck(t *testing.T) {
+	a, err := newAccessControl(AccessConfig{
+		Allow: []string{"10.1.0.0/16"},
+		Deny:  []string{"10.0.0.0/8", "2001:db8::/32"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.ban("198.51.100.9", "test", time.Hour)
+	a.ban("198.51.100.10", "test", -time.Second)
+	a.ban("10.1.2.3", "test", time.Hour)
+
+	tests := []struct {
+		ip     string
+		ok     bool
+		reason string
+	}{
+		{"192.0.2.1", true, ""},
+		{"10.2.3.4", false, "denied"},
+		{"2001:db8::1", false, "denied"},
+		{"10.1.2.4", true, ""}, // allow wins over deny
+		{"10.1.2.3", true, ""}, // and over bans
+		{"198.51.100.9", false, "banned"},
+		{"198.51.100.10", true, ""}, // expired
+		{"not an ip", true, ""},
+	}
+	for _, tt := range tests {
+		ok, reason := a.check(tt.ip)
+		if ok != tt.ok || reason != tt.reason {
+			t.Errorf("check(%s) = %v %q, want %v %q", tt.ip, ok, reason, tt.ok, tt.reason)
+		}
+	}
+}
+
+func TestStrikes(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold int
+		window    time.Duration
+		ip        string
+		strikes   int
+		banned    bool
+	}{
+		{"below threshold", 3, time.Minute, "192.0.2.1", 2, false},
+		{"at threshold", 3, time.Minute, "192.0.2.1", 3, true},
+		{"disabled", 0, time.Minute, "192.0.2.1", 10, false},
+		{"allowed address", 3, time.Minute, "10.0.0.1", 10, false},
+		{"outside the window", 3, -time.Second, "192.0.2.1", 10, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := newAccessControl(AccessConfig{
+				Allow:        []string{"10.0.0.0/8"},
+				BanThreshold: tt.threshold,
+				BanWindow:    Duration(tt.window),
+				BanDuration:  Duration(time.Hour),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tt.strikes; i++ {
+				a.strike(tt.ip, "test")
+			}
+			if got := len(a.listBans()) == 1; got != tt.banned {
+				t.Errorf("banned = %v, want %v", got, tt.banned)
+			}
+		})
+	}
+}
+
+// Bans survive a restart through the ban file, expired ones are dropped
+func TestBanFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "bans.json")
+	a, err := newAccessControl(AccessConfig{BanFile: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.ban("192.0.2.1", "kept", time.Hour)
+	a.ban("192.0.2.2", "lifted", time.Hour)
+	a.ban("192.0.2.3", "expired", time.Millisecond)
+	if !a.unban("192.0.2.2") {
+		t.Error("unban found no ban")
+	}
+	if a.unban("192.0.2.2") {
+		t.Error("second unban found a ban")
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	b, err := newAccessControl(AccessConfig{BanFile: file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bans := b.listBans()
+	if len(bans) != 1 || bans[0].IP != "192.0.2.1" || bans[0].Reason != "kept" {
+		t.Errorf("bans after restart = %+v, want only 192.0.2.1", bans)
+	}
+}
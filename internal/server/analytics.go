@@ -0,0 +1,128 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/analytics"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/tui"
+)
+
+// sessionRecorder collects the analytics events of one session
+type sessionRecorder struct {
+	mu       sync.Mutex
+	session  analytics.Session
+	open     string    // section shown and not left yet
+	openedAt time.Time // when the open section was entered
+}
+
+// visitorKeySize is the length of the secret that visitor IDs of keyless
+// visitors are keyed with
+const visitorKeySize = 32
+
+func newSessionRecorder(key []byte, id, user, ip, fingerprint, term string, start time.Time) *sessionRecorder {
+	return &sessionRecorder{
+		session: analytics.Session{
+			ID:             id,
+			Start:          start,
+			Visitor:        visitorID(key, fingerprint, ip),
+			KeyFingerprint: fingerprint,
+			User:           user,
+			Term:           term,
+		},
+	}
+}
+
+// visitorID identifies a visitor by key when possible, otherwise by an HMAC
+// of the IP. A plain hash of an IPv4 address is reversed by trying them all,
+// the secret key keeps the store from giving raw addresses away.
+func visitorID(key []byte, fingerprint, ip string) string {
+	if fingerprint != "" {
+		return "key:" + fingerprint
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(ip))
+	return "ip:" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// ensureVisitorKey reads the secret that visitor IDs are keyed with from
+// path, generating it on first use so IDs stay stable across restarts
+func ensureVisitorKey(path string) ([]byte, error) {
+	key, err := os.ReadFile(path)
+	if err == nil {
+		if len(key) < visitorKeySize {
+			return nil, fmt.Errorf("visitor key %s is too short", path)
+		}
+		return key, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	key = make([]byte, visitorKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate visitor key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create visitor key directory: %w", err)
+	}
+	if err := os.WriteFile(path, key, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write visitor key: %w", err)
+	}
+
+	slog.Info("generated new visitor key", "file", path)
+	return key, nil
+}
+
+// record is the tui.Model OnEvent hook
+func (r *sessionRecorder) record(e tui.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch e.Kind {
+	case tui.EventSectionEnter:
+		r.open, r.openedAt = e.Section, e.Time
+	case tui.EventSectionLeave:
+		r.open = ""
+	}
+
+	r.session.Events = append(r.session.Events, analytics.Event{
+		Kind:    e.Kind,
+		Time:    e.Time,
+		Section: e.Section,
+		Link:    e.Link,
+		Dwell:   e.Dwell,
+		Enabled: e.Enabled,
+	})
+}
+
+// finish closes the section still open when the connection dropped and
+// returns the completed session
+func (r *sessionRecorder) finish(exitReason string) analytics.Session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if r.open != "" {
+		r.session.Events = append(r.session.Events, analytics.Event{
+			Kind:    analytics.EventSectionLeave,
+			Time:    now,
+			Section: r.open,
+			Dwell:   now.Sub(r.openedAt),
+		})
+		r.open = ""
+	}
+
+	r.session.End = now
+	r.session.ExitReason = exitReason
+	return r.session
+}
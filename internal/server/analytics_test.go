@@ -0,0 +1,74 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVisitorID(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, visitorKeySize)
+	other := bytes.Repeat([]byte{2}, visitorKeySize)
+	plain := sha256.Sum256([]byte("192.0.2.1"))
+
+	tests := []struct {
+		name       string
+		a, b       string
+		same       bool
+		wantPrefix string
+	}{
+		{"key wins over IP", visitorID(key, "SHA256:abc", "192.0.2.1"), "key:SHA256:abc", true, "key:"},
+		{"stable for one key", visitorID(key, "", "192.0.2.1"), visitorID(key, "", "192.0.2.1"), true, "ip:"},
+		{"differs by IP", visitorID(key, "", "192.0.2.1"), visitorID(key, "", "192.0.2.2"), false, "ip:"},
+		{"differs by secret", visitorID(key, "", "192.0.2.1"), visitorID(other, "", "192.0.2.1"), false, "ip:"},
+		{"not a plain hash", visitorID(key, "", "192.0.2.1"), "ip:" + hex.EncodeToString(plain[:8]), false, "ip:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if (tt.a == tt.b) != tt.same {
+				t.Errorf("%q == %q is %v, want %v", tt.a, tt.b, tt.a == tt.b, tt.same)
+			}
+			if !strings.HasPrefix(tt.a, tt.wantPrefix) {
+				t.Errorf("%q does not start with %q", tt.a, tt.wantPrefix)
+			}
+		})
+	}
+}
+
+func TestEnsureVisitorKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "analytics.db.key")
+
+	key, err := ensureVisitorKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key) != visitorKeySize {
+		t.Errorf("generated %d bytes, want %d", len(key), visitorKeySize)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("key file mode = %v, want 0600", mode)
+	}
+
+	again, err := ensureVisitorKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key, again) {
+		t.Error("key changed when read back")
+	}
+
+	if err := os.WriteFile(path, []byte("short"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ensureVisitorKey(path); err == nil {
+		t.Error("short key accepted")
+	}
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+
+	ssh "github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// Everyone is let in. Public key auth is offered first so clients that
+// have a key identify themselves with it; keyboard-interactive without
+// questions lets keyless clients in as before.
+//
+// Keys are not checked with a PublicKeyHandler: charmbracelet/ssh keeps
+// every key a client offers in the session, whether or not it signed with
+// it, so a client could offer an owner's public key, never sign and log in
+// with keyboard-interactive. The handshake only returns the Permissions of
+// the method that succeeded, and a key's only once its signature verified,
+// so the key travels in those instead.
+
+// verifiedKeyExtension holds the wire format of the key a client signed
+// with in its connection's Permissions
+const verifiedKeyExtension = "tuiserver-verified-key"
+
+// sshConfig is the handshake configuration of one connection,
+// keyboard-interactive is added by ssh.Server
+func sshConfig(ctx ssh.Context) *gossh.ServerConfig {
+	return &gossh.ServerConfig{
+		PublicKeyCallback: func(conn gossh.ConnMetadata, key gossh.PublicKey) (*gossh.Permissions, error) {
+			return &gossh.Permissions{
+				Extensions: map[string]string{verifiedKeyExtension: string(key.Marshal())},
+			}, nil
+		},
+	}
+}
+
+func acceptKeyboardInteractive(ctx ssh.Context, challenger gossh.KeyboardInteractiveChallenge) bool {
+	return true
+}
+
+// verifiedKey returns the key the client proved it holds by signing with
+// it, nil for clients that authenticated without one
+func verifiedKey(ctx ssh.Context) ssh.PublicKey {
+	conn, ok := ctx.Value(ssh.ContextKeyConn).(*gossh.ServerConn)
+	if !ok || conn.Permissions == nil {
+		return nil
+	}
+	data, ok := conn.Permissions.Extensions[verifiedKeyExtension]
+	if !ok {
+		return nil
+	}
+	key, err := gossh.ParsePublicKey([]byte(data))
+	if err != nil {
+		return nil
+	}
+	return key
+}
+
+// keyFingerprint returns the SHA256 fingerprint of the key the client
+// authenticated with, or "" for keyless clients
+func keyFingerprint(key ssh.PublicKey) string {
+	if key != nil {
+		return gossh.FingerprintSHA256(key)
+	}
+	return ""
+}
+
+// loadAuthorizedKeys reads public keys in OpenSSH authorized_keys format.
+// Options before a key are accepted and ignored.
+func loadAuthorizedKeys(path string) ([]ssh.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read authorized keys: %w", err)
+	}
+
+	var keys []ssh.PublicKey
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		key, _, _, _, err := gossh.ParseAuthorizedKey(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key on line %d of %s: %w", n, path, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, scanner.Err()
+}
+
+// readOwners reads the owner keys, none when path is empty
+func readOwners(path string) ([]ssh.PublicKey, error) {
+	if path == "" {
+		return nil, nil
+	}
+	owners, err := loadAuthorizedKeys(path)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("loaded owner keys", "file", path, "keys", len(owners))
+	return owners, nil
+}
+
+func (srv *Server) setOwners(owners []ssh.PublicKey) {
+	srv.keysMu.Lock()
+	srv.owners = owners
+	srv.keysMu.Unlock()
+}
+
+// readPrivateKeys reads the keys that see private sections, none when path
+// is empty
+func readPrivateKeys(path string) ([]ssh.PublicKey, error) {
+	if path == "" {
+		return nil, nil
+	}
+	keys, err := loadAuthorizedKeys(path)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("loaded private section keys", "file", path, "keys", len(keys))
+	return keys, nil
+}
+
+func (srv *Server) setPrivateKeys(keys []ssh.PublicKey) {
+	srv.keysMu.Lock()
+	srv.privateKeys = keys
+	srv.keysMu.Unlock()
+}
+
+// isOwner reports whether a session authenticated with an owner key, key
+// being the one from verifiedKey
+func (srv *Server) isOwner(key ssh.PublicKey) bool {
+	srv.keysMu.RLock()
+	defer srv.keysMu.RUnlock()
+	return keyListed(key, srv.owners)
+}
+
+// isPrivateKey reports whether a session authenticated with a key allowed
+// to see private sections
+func (srv *Server) isPrivateKey(key ssh.PublicKey) bool {
+	srv.keysMu.RLock()
+	defer srv.keysMu.RUnlock()
+	return keyListed(key, srv.privateKeys)
+}
+
+func keyListed(key ssh.PublicKey, list []ssh.PublicKey) bool {
+	if key == nil {
+		return false
+	}
+	for _, listed := range list {
+		if ssh.KeysEqual(key, listed) {
+			return true
+		}
+	}
+	return false
+}
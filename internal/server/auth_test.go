@@ -0,0 +1,283 @@
+package server
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ssh "github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// testConfig is the default config without any state files
+func testConfig() Config {
+	config := DefaultConfig()
+	config.Access.BanFile = ""
+	config.Analytics.File = ""
+	config.Visitors.File = ""
+	config.Private.InviteFile = ""
+	config.Log.File = ""
+	config.Control.Socket = ""
+	return config
+}
+
+func newTestServer(t *testing.T, config Config) *Server {
+	t.Helper()
+	access, err := newAccessControl(config.Access)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &Server{
+		limiter:  newLimiter(config.Limits),
+		access:   access,
+		sessions: newSessionRegistry(),
+		started:  time.Now(),
+		signals:  make(chan os.Signal, 1),
+	}
+	srv.cfg.Store(&config)
+	return srv
+}
+
+// startSSH serves srv's SSH server on a loopback port, with handler in
+// place of the session handler when it is not nil
+func startSSH(t *testing.T, srv *Server, handler ssh.Handler) string {
+	t.Helper()
+	server := srv.sshServer()
+	if handler != nil {
+		server.Handler = handler
+	}
+	server.AddHostKey(newSigner(t))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(ln)
+	t.Cleanup(func() { server.Close() })
+	return ln.Addr().String()
+}
+
+func newSigner(t *testing.T) gossh.Signer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := gossh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// writeKey generates a key and writes it in OpenSSH format to dir as name
+// and name.pub
+func writeKey(t *testing.T, dir, name string) gossh.Signer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := gossh.MarshalPrivateKey(priv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := gossh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".pub", gossh.MarshalAuthorizedKey(signer.PublicKey()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// keyResult is what a test handler saw of a session's key
+type keyResult struct {
+	owner   bool
+	private bool
+}
+
+// recordKeys answers every session and reports what its key unlocks
+func recordKeys(srv *Server, results chan<- keyResult) ssh.Handler {
+	return func(s ssh.Session) {
+		key := verifiedKey(s.Context())
+		results <- keyResult{owner: srv.isOwner(key), private: srv.isPrivateKey(key)}
+		s.Exit(0)
+	}
+}
+
+func dialAndRun(addr, user string, auth ...gossh.AuthMethod) error {
+	client, err := gossh.Dial("tcp", addr, &gossh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	return session.Run("true")
+}
+
+func waitResult(t *testing.T, results <-chan keyResult) keyResult {
+	t.Helper()
+	select {
+	case r := <-results:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("session handler was not called")
+		return keyResult{}
+	}
+}
+
+func TestVerifiedKey(t *testing.T) {
+	owner := newSigner(t)
+	other := newSigner(t)
+	srv := newTestServer(t, testConfig())
+	srv.setOwners([]ssh.PublicKey{owner.PublicKey()})
+	results := make(chan keyResult, 1)
+	addr := startSSH(t, srv, recordKeys(srv, results))
+
+	noQuestions := gossh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+		return nil, nil
+	})
+	tests := []struct {
+		name  string
+		auth  []gossh.AuthMethod
+		owner bool
+	}{
+		{"owner key", []gossh.AuthMethod{gossh.PublicKeys(owner)}, true},
+		{"other key", []gossh.AuthMethod{gossh.PublicKeys(other)}, false},
+		{"keyboard-interactive", []gossh.AuthMethod{noQuestions}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := dialAndRun(addr, "visitor", tt.auth...); err != nil {
+				t.Fatal(err)
+			}
+			if got := waitResult(t, results); got.owner != tt.owner {
+				t.Errorf("owner = %v, want %v", got.owner, tt.owner)
+			}
+		})
+	}
+}
+
+// forgedSigner claims a key it cannot sign for
+type forgedSigner struct {
+	gossh.Signer
+	claimed gossh.PublicKey
+}
+
+func (f forgedSigner) PublicKey() gossh.PublicKey { return f.claimed }
+
+func TestForgedSignatureIsRejected(t *testing.T) {
+	owner := newSigner(t)
+	srv := newTestServer(t, testConfig())
+	srv.setOwners([]ssh.PublicKey{owner.PublicKey()})
+	results := make(chan keyResult, 1)
+	addr := startSSH(t, srv, recordKeys(srv, results))
+
+	forged := forgedSigner{Signer: newSigner(t), claimed: owner.PublicKey()}
+	if err := dialAndRun(addr, "visitor", gossh.PublicKeys(forged)); err == nil {
+		t.Fatal("handshake with a forged signature succeeded")
+	}
+	select {
+	case <-results:
+		t.Fatal("session started with a forged signature")
+	default:
+	}
+}
+
+// openSSH runs the OpenSSH client against addr, authenticating with the
+// identity file or falling back to keyboard-interactive. Without a command
+// it requests a terminal and types input into it.
+func openSSH(t *testing.T, addr, user, identity, input string, command ...string) ([]byte, error) {
+	t.Helper()
+	path, err := exec.LookPath("ssh")
+	if err != nil {
+		t.Skip("OpenSSH client not installed")
+	}
+	host, port, _ := net.SplitHostPort(addr)
+	args := []string{
+		"-F", "/dev/null",
+		"-i", identity,
+		"-o", "IdentitiesOnly=yes",
+		"-o", "IdentityAgent=none",
+		"-o", "PreferredAuthentications=publickey,keyboard-interactive",
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=/dev/null",
+		"-o", "LogLevel=ERROR",
+		"-o", "ConnectTimeout=5",
+		"-p", port,
+	}
+	if len(command) == 0 {
+		args = append(args, "-tt")
+	}
+	args = append(append(args, user+"@"+host), command...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = strings.NewReader(input)
+	return cmd.CombinedOutput()
+}
+
+// The client offers the owner's public key, which the server accepts, but
+// cannot sign with it and logs in with keyboard-interactive instead. The
+// offered key must not count as the session's key.
+func TestUnsignedOwnerKeyIsNotTrusted(t *testing.T) {
+	dir := t.TempDir()
+	owner := writeKey(t, dir, "owner")
+	srv := newTestServer(t, testConfig())
+	srv.setOwners([]ssh.PublicKey{owner.PublicKey()})
+	results := make(chan keyResult, 1)
+	addr := startSSH(t, srv, recordKeys(srv, results))
+
+	tests := []struct {
+		name     string
+		identity string
+		owner    bool
+	}{
+		{"private key", filepath.Join(dir, "owner"), true},
+		{"public key only", filepath.Join(dir, "owner.pub"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if out, err := openSSH(t, addr, "visitor", tt.identity, "", "true"); err != nil {
+				t.Fatalf("ssh failed: %v\n%s", err, out)
+			}
+			if got := waitResult(t, results); got.owner != tt.owner {
+				t.Errorf("owner = %v, want %v", got.owner, tt.owner)
+			}
+		})
+	}
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	if got := keyFingerprint(nil); got != "" {
+		t.Errorf("keyFingerprint(nil) = %q, want empty", got)
+	}
+	signer := newSigner(t)
+	if got := keyFingerprint(signer.PublicKey()); !strings.HasPrefix(got, "SHA256:") {
+		t.Errorf("keyFingerprint = %q, want a SHA256 fingerprint", got)
+	}
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"log/slog"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/tui"
+)
+
+// broadcast shows a dismissible announcement to every live session and
+// returns how many got it
+func (srv *Server) broadcast(text string) int {
+	n := srv.sessions.sendAll(tui.BroadcastMsg(text))
+	slog.Info("broadcast", "message", text, "sessions", n)
+	return n
+}
+
+// setMaintenance shows a banner to every session, including those started
+// later, until it is cleared with an empty text
+func (srv *Server) setMaintenance(text string) int {
+	// held while sending so a session registered by addSession either gets
+	// the message or already read the new banner
+	srv.maintenanceMu.Lock()
+	srv.maintenance = text
+	n := srv.sessions.sendAll(tui.MaintenanceMsg(text))
+	srv.maintenanceMu.Unlock()
+
+	if text == "" {
+		slog.Info("maintenance banner cleared", "sessions", n)
+	} else {
+		slog.Info("maintenance banner set", "message", text, "sessions", n)
+	}
+	return n
+}
+
+// maintenanceBanner returns the maintenance banner, "" when there is none
+func (srv *Server) maintenanceBanner() string {
+	srv.maintenanceMu.Lock()
+	defer srv.maintenanceMu.Unlock()
+	return srv.maintenance
+}
+
+// addSession registers a session until the returned func is called, and
+// returns the maintenance banner as of then. The session's model was built
+// with an earlier banner, one set or cleared in between must still be
+// sent to it.
+func (srv *Server) addSession(live *liveSession) (func(), string) {
+	srv.maintenanceMu.Lock()
+	defer srv.maintenanceMu.Unlock()
+	return srv.sessions.add(live), srv.maintenance
+}
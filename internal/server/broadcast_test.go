@@ -0,0 +1,142 @@
+package server
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/tui"
+)
+
+// msgModel passes the banner messages it gets on to a channel
+type msgModel struct {
+	msgs chan tea.Msg
+}
+
+func (m msgModel) Init() tea.Cmd { return nil }
+
+func (m msgModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg.(type) {
+	case tui.BroadcastMsg, tui.MaintenanceMsg:
+		m.msgs <- msg
+	}
+	return m, nil
+}
+
+func (m msgModel) View() string { return "" }
+
+// startLive runs a program that records its messages, registered with srv
+// like a TUI session
+func startLive(t *testing.T, srv *Server, id string) (*liveSession, <-chan tea.Msg) {
+	t.Helper()
+	msgs := make(chan tea.Msg, 10)
+	p := tea.NewProgram(msgModel{msgs},
+		tea.WithInput(nil), tea.WithOutput(io.Discard), tea.WithoutRenderer(), tea.WithoutSignalHandler())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.Run()
+	}()
+	t.Cleanup(func() {
+		p.Quit()
+		<-done
+	})
+
+	live := &liveSession{program: p, info: SessionInfo{ID: id}}
+	remove, _ := srv.addSession(live)
+	t.Cleanup(remove)
+	return live, msgs
+}
+
+func receive(t *testing.T, msgs <-chan tea.Msg) tea.Msg {
+	t.Helper()
+	select {
+	case msg := <-msgs:
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message delivered")
+		return nil
+	}
+}
+
+func TestBannerDelivery(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(srv *Server) int
+		want tea.Msg
+	}{
+		{"broadcast", func(srv *Server) int { return srv.broadcast("hello") }, tui.BroadcastMsg("hello")},
+		{"maintenance set", func(srv *Server) int { return srv.setMaintenance("restarting soon") }, tui.MaintenanceMsg("restarting soon")},
+		{"maintenance cleared", func(srv *Server) int { return srv.setMaintenance("") }, tui.MaintenanceMsg("")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, testConfig())
+			_, first := startLive(t, srv, "a")
+			_, second := startLive(t, srv, "b")
+
+			if n := tt.send(srv); n != 2 {
+				t.Errorf("sent to %d sessions, want 2", n)
+			}
+			for _, msgs := range []<-chan tea.Msg{first, second} {
+				if got := receive(t, msgs); got != tt.want {
+					t.Errorf("got %#v, want %#v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// Sessions registered after the banner was set learn it from addSession
+func TestMaintenanceForLaterSessions(t *testing.T) {
+	srv := newTestServer(t, testConfig())
+	if n := srv.setMaintenance("restarting soon"); n != 0 {
+		t.Errorf("sent to %d sessions, want none", n)
+	}
+	if got := srv.maintenanceBanner(); got != "restarting soon" {
+		t.Errorf("banner = %q", got)
+	}
+
+	remove, banner := srv.addSession(&liveSession{info: SessionInfo{ID: "late"}})
+	defer remove()
+	if banner != "restarting soon" {
+		t.Errorf("addSession banner = %q, want the one set before", banner)
+	}
+}
+
+// A banner set while sessions register reaches every one of them, through
+// the message or through addSession, run with -race
+func TestMaintenanceWhileSessionsStart(t *testing.T) {
+	srv := newTestServer(t, testConfig())
+	type started struct {
+		msgs   <-chan tea.Msg
+		banner string
+	}
+	results := make(chan started, 20)
+	for i := 0; i < 20; i++ {
+		id := string(rune('a' + i))
+		go func() {
+			msgs := make(chan tea.Msg, 10)
+			p := tea.NewProgram(msgModel{msgs},
+				tea.WithInput(nil), tea.WithOutput(io.Discard), tea.WithoutRenderer(), tea.WithoutSignalHandler())
+			go p.Run()
+			t.Cleanup(p.Quit)
+			remove, banner := srv.addSession(&liveSession{program: p, info: SessionInfo{ID: id}})
+			t.Cleanup(remove)
+			results <- started{msgs, banner}
+		}()
+	}
+	srv.setMaintenance("restarting soon")
+
+	for i := 0; i < 20; i++ {
+		s := <-results
+		if s.banner == "restarting soon" {
+			continue
+		}
+		if got := receive(t, s.msgs); got != tui.MaintenanceMsg("restarting soon") {
+			t.Errorf("session missed the banner, got %#v", got)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	ssh "github.com/charmbracelet/ssh"
+)
+
+// adminCommand handles one `ssh host <command> [args]` exec request or
+// `tuiserver ctl <command> [args]` control request
+type adminCommand struct {
+	usage       string
+	controlOnly bool // refused over SSH, where any local user can connect
+	run         func(srv *Server, w io.Writer, args []string) error
+}
+
+var adminCommands = map[string]adminCommand{
+	"bans": {
+		usage: "bans                      list active bans",
+		run: func(srv *Server, w io.Writer, args []string) error {
+			return writeBans(w, srv.access.listBans())
+		},
+	},
+	"ban": {
+		usage: "ban <ip> [duration]       ban an address (default: ban_duration)",
+		run: func(srv *Server, w io.Writer, args []string) error {
+			if len(args) < 1 || net.ParseIP(args[0]) == nil {
+				return fmt.Errorf("usage: ban <ip> [duration]")
+			}
+			duration := time.Duration(srv.config().Access.BanDuration)
+			if len(args) > 1 {
+				d, err := time.ParseDuration(args[1])
+				if err != nil {
+					return fmt.Errorf("invalid duration: %w", err)
+				}
+				duration = d
+			}
+			srv.access.ban(args[0], "manual", duration)
+			fmt.Fprintf(w, "banned %s until %s\n", args[0], time.Now().Add(duration).Format(time.RFC3339))
+			return nil
+		},
+	},
+	"broadcast": {
+		usage: "broadcast <message>       show an announcement to every visitor",
+		run: func(srv *Server, w io.Writer, args []string) error {
+			if len(args) < 1 {
+				return fmt.Errorf("usage: broadcast <message>")
+			}
+			n := srv.broadcast(strings.Join(args, " "))
+			fmt.Fprintf(w, "sent to %d sessions\n", n)
+			return nil
+		},
+	},
+	"reload-config": {
+		usage: "reload-config             apply config file and environment changes",
+		run: func(srv *Server, w io.Writer, args []string) error {
+			restart, err := srv.reloadConfig()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(w, "config reloaded")
+			if len(restart) > 0 {
+				fmt.Fprintf(w, "restart to apply: %s\n", strings.Join(restart, ", "))
+			}
+			return nil
+		},
+	},
+	"reload-content": {
+		usage: "reload-content            serve the content file to new sessions",
+		run: func(srv *Server, w io.Writer, args []string) error {
+			p, err := srv.reloadContent()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "content reloaded, %d sections\n", len(p.Sections))
+			return nil
+		},
+	},
+	"maintenance": {
+		usage: "maintenance <message|off> show a banner to all sessions until off",
+		run: func(srv *Server, w io.Writer, args []string) error {
+			if len(args) < 1 {
+				if banner := srv.maintenanceBanner(); banner != "" {
+					fmt.Fprintf(w, "maintenance banner: %s\n", banner)
+				} else {
+					fmt.Fprintln(w, "no maintenance banner")
+				}
+				return nil
+			}
+			text := strings.Join(args, " ")
+			if text == "off" {
+				text = ""
+			}
+			n := srv.setMaintenance(text)
+			fmt.Fprintf(w, "updated %d sessions\n", n)
+			return nil
+		},
+	},
+	"sessions": {
+		usage: "sessions                  list live sessions",
+		run: func(srv *Server, w io.Writer, args []string) error {
+			return writeSessions(w, srv.sessions.list())
+		},
+	},
+	"shutdown": {
+		usage:       "shutdown                  stop accepting and let sessions finish, like SIGTERM",
+		controlOnly: true,
+		run: func(srv *Server, w io.Writer, args []string) error {
+			if srv.draining.Load() {
+				return fmt.Errorf("already shutting down")
+			}
+			select {
+			case srv.signals <- syscall.SIGTERM:
+			default:
+			}
+			fmt.Fprintf(w, "shutting down, %d sessions get up to %s to finish\n",
+				len(srv.sessions.list()), time.Duration(srv.config().ShutdownTimeout))
+			return nil
+		},
+	},
+	"status": {
+		usage: "status                    show what the server is doing",
+		run: func(srv *Server, w io.Writer, args []string) error {
+			return srv.writeStatus(w)
+		},
+	},
+	"unban": {
+		usage: "unban <ip>                lift a ban",
+		run: func(srv *Server, w io.Writer, args []string) error {
+			if len(args) < 1 {
+				return fmt.Errorf("usage: unban <ip>")
+			}
+			if !srv.access.unban(args[0]) {
+				return fmt.Errorf("%s is not banned", args[0])
+			}
+			fmt.Fprintf(w, "unbanned %s\n", args[0])
+			return nil
+		},
+	},
+}
+
+// handleCommand runs an exec request. Commands are only accepted from the
+// loopback interface, i.e. from the machine the server runs on, and from a
+// client that signed with an owner key: other local users, and visitors
+// coming through a local reverse proxy, connect from loopback too.
+func (srv *Server) handleCommand(s ssh.Session, logger *slog.Logger, args []string) {
+	ip := net.ParseIP(remoteIP(s.RemoteAddr()))
+	if ip == nil || !ip.IsLoopback() || !srv.isOwner(verifiedKey(s.Context())) {
+		fmt.Fprintln(s.Stderr(), "commands are not available, connect with `ssh -t` for the portfolio")
+		logger.Warn("command refused", "command", args[0])
+		s.Exit(1)
+		return
+	}
+
+	logger.Info("command", "command", strings.Join(args, " "))
+
+	if err := srv.runAdminCommand(s, args, false); err != nil {
+		fmt.Fprintln(s.Stderr(), err)
+		s.Exit(1)
+		return
+	}
+	s.Exit(0)
+}
+
+// runAdminCommand runs one command, viaControl telling whether it came
+// over the control socket. help and unknown commands list the commands.
+func (srv *Server) runAdminCommand(w io.Writer, args []string, viaControl bool) error {
+	if len(args) == 0 || args[0] == "help" {
+		writeUsage(w, viaControl)
+		return nil
+	}
+
+	cmd, ok := adminCommands[args[0]]
+	if !ok {
+		var usage strings.Builder
+		writeUsage(&usage, viaControl)
+		return fmt.Errorf("unknown command %q, available commands:\n%s", args[0], strings.TrimRight(usage.String(), "\n"))
+	}
+	if cmd.controlOnly && !viaControl {
+		return fmt.Errorf("%s is only available through `tuiserver ctl`", args[0])
+	}
+	return cmd.run(srv, w, args[1:])
+}
+
+// writeUsage lists the commands available over SSH or the control socket
+func writeUsage(w io.Writer, viaControl bool) {
+	names := make([]string, 0, len(adminCommands))
+	for name, cmd := range adminCommands {
+		if viaControl || !cmd.controlOnly {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", adminCommands[name].usage)
+	}
+}
+
+// writeBans prints bans as a table
+func writeBans(w io.Writer, bans []Ban) error {
+	if len(bans) == 0 {
+		_, err := fmt.Fprintln(w, "no active bans")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "IP\tREASON\tEXPIRES")
+	for _, b := range bans {
+		fmt.Fprintf(tw, "%s\t%s\t%s (in %s)\n", b.IP, b.Reason,
+			b.Until.Format(time.RFC3339), time.Until(b.Until).Round(time.Second))
+	}
+	return tw.Flush()
+}
+
+// writeSessions prints live sessions as a table
+func writeSessions(w io.Writer, sessions []SessionInfo) error {
+	if len(sessions) == 0 {
+		_, err := fmt.Fprintln(w, "nobody is connected")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "USER\tIP\tVIA\tTERM\tSIZE\tSECTION\tTIME")
+	for _, s := range sessions {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", s.User, s.IP, s.Transport, s.Term,
+			formatSize(s.Width, s.Height), s.Section, time.Since(s.Started).Round(time.Second))
+	}
+	return tw.Flush()
+}
+
+// writeStatus prints an overview of the running server
+func (srv *Server) writeStatus(w io.Writer) error {
+	sessions := srv.sessions.list()
+	byTransport := make(map[string]int)
+	for _, s := range sessions {
+		byTransport[s.Transport]++
+	}
+	var counts []string
+	for _, transport := range []string{"ssh", "web", "telnet"} {
+		if n := byTransport[transport]; n > 0 {
+			counts = append(counts, fmt.Sprintf("%s %d", transport, n))
+		}
+	}
+	sessionText := fmt.Sprint(len(sessions))
+	if len(counts) > 0 {
+		sessionText += " (" + strings.Join(counts, ", ") + ")"
+	}
+
+	content := srv.content()
+	source := srv.config().ContentPath
+	if source == "" {
+		source = "built-in"
+	}
+	maintenance := srv.maintenanceBanner()
+	if maintenance == "" {
+		maintenance = "off"
+	}
+	state := "serving"
+	if srv.draining.Load() {
+		state = "draining"
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "state\t%s\n", state)
+	fmt.Fprintf(tw, "version\t%s\n", Version)
+	fmt.Fprintf(tw, "pid\t%d\n", os.Getpid())
+	fmt.Fprintf(tw, "uptime\t%s\n", time.Since(srv.started).Round(time.Second))
+	fmt.Fprintf(tw, "sessions\t%s\n", sessionText)
+	fmt.Fprintf(tw, "content\t%s, %d sections, %d visible\n", source, len(content.Sections), len(content.Visible().Sections))
+	fmt.Fprintf(tw, "maintenance\t%s\n", maintenance)
+	fmt.Fprintf(tw, "bans\t%d\n", len(srv.access.listBans()))
+	for _, b := range srv.bound {
+		fmt.Fprintf(tw, "listening\t%s %s\n", b.service, b.ln.Addr())
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	ssh "github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+func TestCommandsNeedOwnerKey(t *testing.T) {
+	owner := newSigner(t)
+	srv := newTestServer(t, testConfig())
+	srv.setOwners([]ssh.PublicKey{owner.PublicKey()})
+	addr := startSSH(t, srv, nil)
+
+	noQuestions := gossh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+		return nil, nil
+	})
+	tests := []struct {
+		name string
+		auth gossh.AuthMethod
+		ok   bool
+	}{
+		{"owner key", gossh.PublicKeys(owner), true},
+		{"other key", gossh.PublicKeys(newSigner(t)), false},
+		{"keyboard-interactive", noQuestions, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := gossh.Dial("tcp", addr, &gossh.ClientConfig{
+				User:            "visitor",
+				Auth:            []gossh.AuthMethod{tt.auth},
+				HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+			session, err := client.NewSession()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer session.Close()
+
+			var stdout, stderr bytes.Buffer
+			session.Stdout, session.Stderr = &stdout, &stderr
+			err = session.Run("help")
+			var exit *gossh.ExitError
+			if err != nil && !errors.As(err, &exit) {
+				t.Fatal(err)
+			}
+			if ok := err == nil; ok != tt.ok {
+				t.Fatalf("command accepted = %v, want %v (stderr %q)", ok, tt.ok, stderr.String())
+			}
+			if tt.ok && !strings.Contains(stdout.String(), "ban") {
+				t.Errorf("help output lists no commands: %q", stdout.String())
+			}
+			if !tt.ok && !strings.Contains(stderr.String(), "not available") {
+				t.Errorf("refusal not explained: %q", stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunAdminCommand(t *testing.T) {
+	srv := newTestServer(t, testConfig())
+	tests := []struct {
+		name       string
+		args       []string
+		viaControl bool
+		err        string
+	}{
+		{"help", []string{"help"}, false, ""},
+		{"no command", nil, true, ""},
+		{"unknown", []string{"frobnicate"}, false, `unknown command "frobnicate"`},
+		{"control only over ssh", []string{"shutdown"}, false, "only available through `tuiserver ctl`"},
+		{"ban without address", []string{"ban"}, true, "usage: ban"},
+		{"unban unknown", []string{"unban", "192.0.2.1"}, true, "is not banned"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := srv.runAdminCommand(&out, tt.args, tt.viaControl)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Fatalf("error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,488 @@
+package server
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is prepended to every environment variable read by LoadConfig
+const EnvPrefix = "TUISERVER_"
+
+// ssh server configuration
+//
+// The effective configuration is built in layers, each one overriding the
+// previous: DefaultConfig, then the JSON config file, then TUISERVER_*
+// environment variables, then command line flags.
+//
+// Environment variable names are derived from the JSON keys, nested
+// sections joined with an underscore, e.g. TUISERVER_LISTEN_ADDR or
+// TUISERVER_LIMITS_IDLE_TIMEOUT. Lists are comma separated.
+type Config struct {
+	ListenAddrs Addresses       `json:"listen_addr"`  // SSH listen addresses, unused with socket activation
+	HostKeys    []string        `json:"host_keys"`    // SSH host key files, generated when missing
+	ContentPath string          `json:"content_path"` // portfolio JSON file, built-in content when empty
+	StateDir    string          `json:"state_dir"`    // where bare log and state file names are kept, $STATE_DIRECTORY or the working directory when empty
+	Limits      LimitsConfig    `json:"limits"`
+	Access      AccessConfig    `json:"access"`
+	Admin       AdminConfig     `json:"admin"`
+	Proxy       ProxyConfig     `json:"proxy_protocol"`
+	Log         LogConfig       `json:"log"`
+	Metrics     MetricsConfig   `json:"metrics"`
+	Health      HealthConfig    `json:"health"`
+	Web         WebConfig       `json:"web"`
+	Finger      FingerConfig    `json:"finger"`
+	Gemini      GeminiConfig    `json:"gemini"`
+	Gopher      GopherConfig    `json:"gopher"`
+	Telnet      TelnetConfig    `json:"telnet"`
+	Analytics   AnalyticsConfig `json:"analytics"`
+	Recording   RecordingConfig `json:"recording"`
+	Visitors    VisitorsConfig  `json:"visitors"`
+	Private     PrivateConfig   `json:"private"`
+	Templates   TemplatesConfig `json:"templates"`
+	Features    FeaturesConfig  `json:"features"`
+	Control     ControlConfig   `json:"control"`
+
+	ShutdownTimeout     Duration `json:"shutdown_timeout"`      // how long running sessions may take to finish on shutdown
+	UpgradeDrainTimeout Duration `json:"upgrade_drain_timeout"` // how long the old process keeps serving sessions after SIGUSR2
+
+	// Reload builds the configuration again from the same layers, for
+	// `tuiserver ctl reload-config`. Reloading is unavailable when nil.
+	Reload func() (Config, error) `json:"-"`
+}
+
+// session limits, 0 disables a limit
+//
+// The caps and the rate apply to SSH connections as well, before their
+// handshake: a connection counts against max_sessions and
+// max_sessions_per_ip from the moment it is accepted, and the sessions it
+// opens count again.
+type LimitsConfig struct {
+	MaxSessions        int      `json:"max_sessions"`         // concurrent sessions across all clients, and SSH connections
+	MaxSessionsPerIP   int      `json:"max_sessions_per_ip"`  // concurrent sessions from one IP, and SSH connections
+	ConnRatePerMinute  int      `json:"conn_rate_per_minute"` // new sessions and SSH connections per IP, refilled over a minute
+	ConnBurst          int      `json:"conn_burst"`           // new sessions per IP allowed at once
+	IdleTimeout        Duration `json:"idle_timeout"`         // disconnect after no keypress for this long
+	IdleWarning        Duration `json:"idle_warning"`         // show a countdown this long before disconnecting
+	MaxSessionDuration Duration `json:"max_session_duration"` // absolute session lifetime
+	HandshakeTimeout   Duration `json:"handshake_timeout"`    // time an SSH client has to log in and open a session
+}
+
+// address filtering and automatic bans
+type AccessConfig struct {
+	Allow             []string `json:"allow"`              // IPs or CIDRs that bypass deny entries and bans
+	Deny              []string `json:"deny"`               // IPs or CIDRs refused before the SSH handshake
+	BanFile           string   `json:"ban_file"`           // where bans are kept across restarts
+	BanThreshold      int      `json:"ban_threshold"`      // suspicious connections before a ban, 0 disables
+	BanWindow         Duration `json:"ban_window"`         // period the suspicious connections are counted over
+	BanDuration       Duration `json:"ban_duration"`       // how long an automatic ban lasts
+	InstantDisconnect Duration `json:"instant_disconnect"` // sessions shorter than this count as suspicious
+}
+
+// site owners, who can edit the content from their SSH session
+type AdminConfig struct {
+	AuthorizedKeys string `json:"authorized_keys"` // owner public keys in authorized_keys format, disabled when empty
+	ConsoleUser    string `json:"console_user"`    // SSH user name that opens the live session console for owners
+}
+
+// PROXY protocol v1/v2 on the SSH listener, for load balancers such as
+// HAProxy. Only trusted sources may send a header and they must send one.
+type ProxyConfig struct {
+	Trusted       []string `json:"trusted"`        // load balancer IPs or CIDRs, disabled when empty
+	HeaderTimeout Duration `json:"header_timeout"` // how long a trusted source has to send the header
+}
+
+// connection log settings
+type LogConfig struct {
+	File        string   `json:"file"`         // connection log file, stdout only when empty
+	Format      string   `json:"format"`       // "text" or "json"
+	Level       string   `json:"level"`        // "debug", "info", "warn" or "error"
+	Stdout      bool     `json:"stdout"`       // also write to stdout
+	MaxSizeMB   int      `json:"max_size_mb"`  // rotate once the file grows past this size, 0 disables
+	RotateEvery Duration `json:"rotate_every"` // rotate after the file has been open this long, 0 disables
+	MaxBackups  int      `json:"max_backups"`  // rotated files to keep, 0 keeps all
+	MaxAge      Duration `json:"max_age"`      // delete rotated files older than this, 0 keeps all
+}
+
+// Prometheus endpoint
+type MetricsConfig struct {
+	ListenAddr string `json:"listen_addr"` // HTTP address serving /metrics, disabled when empty
+}
+
+// health, readiness and version endpoints
+type HealthConfig struct {
+	ListenAddr string `json:"listen_addr"` // HTTP address serving /healthz, /readyz and /version, disabled when empty
+}
+
+// portfolio over HTTP
+type WebConfig struct {
+	ListenAddr string `json:"listen_addr"` // HTTP address serving the portfolio, disabled when empty
+	Terminal   bool   `json:"terminal"`    // serve the interactive TUI to browsers on /terminal
+}
+
+// RFC 1288 finger listener, with limits of its own
+type FingerConfig struct {
+	ListenAddr        string `json:"listen_addr"`          // finger address, usually ":79", disabled when empty
+	MaxConnections    int    `json:"max_connections"`      // concurrent queries across all clients
+	ConnRatePerMinute int    `json:"conn_rate_per_minute"` // queries per IP, refilled over a minute
+	ConnBurst         int    `json:"conn_burst"`           // queries per IP allowed at once
+}
+
+// Gemini capsule, TLS with a self-signed certificate generated when missing
+type GeminiConfig struct {
+	ListenAddr        string `json:"listen_addr"`          // gemini address, usually ":1965", disabled when empty
+	Hostname          string `json:"hostname"`             // host name the certificate is issued for and requests must name
+	CertFile          string `json:"cert_file"`            // TLS certificate, generated with the key when missing
+	KeyFile           string `json:"key_file"`             // TLS private key
+	MaxConnections    int    `json:"max_connections"`      // concurrent requests across all clients
+	ConnRatePerMinute int    `json:"conn_rate_per_minute"` // requests per IP, refilled over a minute
+	ConnBurst         int    `json:"conn_burst"`           // requests per IP allowed at once
+}
+
+// RFC 1436 gopher listener
+type GopherConfig struct {
+	ListenAddr        string `json:"listen_addr"`          // gopher address, usually ":70", disabled when empty
+	Hostname          string `json:"hostname"`             // host name menu items point clients back to
+	MaxConnections    int    `json:"max_connections"`      // concurrent requests across all clients
+	ConnRatePerMinute int    `json:"conn_rate_per_minute"` // requests per IP, refilled over a minute
+	ConnBurst         int    `json:"conn_burst"`           // requests per IP allowed at once
+}
+
+// telnet listener for the TUI, sharing the session limits with SSH
+type TelnetConfig struct {
+	ListenAddr string `json:"listen_addr"` // telnet address, usually ":23", disabled when empty
+}
+
+// visitor analytics
+type AnalyticsConfig struct {
+	File string `json:"file"` // analytics database, disabled when empty
+}
+
+// asciicast v2 recordings of what sessions were shown, never what was typed.
+// Owner sessions are not recorded.
+type RecordingConfig struct {
+	Dir        string   `json:"dir"`         // directory recordings are written to, disabled when empty
+	SampleRate float64  `json:"sample_rate"` // share of sessions recorded, from 0 to 1
+	MaxAge     Duration `json:"max_age"`     // delete recordings older than this, 0 keeps all
+	MaxFiles   int      `json:"max_files"`   // keep only the newest recordings, 0 keeps all
+}
+
+// returning visitors, recognized by the key they connect with
+type VisitorsConfig struct {
+	File string `json:"file"` // visit history and content revisions, disabled when empty
+}
+
+// private sections, shown to owners, allowed keys and visitors who connect
+// with an invite code as their user name
+type PrivateConfig struct {
+	AuthorizedKeys string `json:"authorized_keys"` // public keys that see private sections, in authorized_keys format
+	InviteFile     string `json:"invite_file"`     // invites made with `tuiserver invite`, disabled when empty
+}
+
+// template expressions in content lines, rendered for each session
+type TemplatesConfig struct {
+	DataFile string `json:"data_file"` // JSON object of values for {{.Data.name}}, read when a session starts
+}
+
+// optional TUI behaviour
+type FeaturesConfig struct {
+	WelcomeScreen bool `json:"welcome_screen"` // show the title splash on connect
+	Mouse         bool `json:"mouse"`          // enable mouse cell motion events
+}
+
+// local control socket used by `tuiserver ctl`
+type ControlConfig struct {
+	Socket string `json:"socket"` // Unix socket path, only the service user may connect, disabled when empty
+}
+
+// Duration is a time.Duration that reads and writes as "5m", "30s", ...
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Addresses is a list of listen addresses, e.g. ":22" and "[::]:2222". The
+// config file accepts a single address string as well as a list.
+type Addresses []string
+
+func (a *Addresses) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = nil
+		if single != "" {
+			*a = Addresses{single}
+		}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func DefaultConfig() Config {
+	defaultLogPath := "tuiserver_connections.log"
+
+	return Config{
+		ListenAddrs: Addresses{":2222"},
+		Limits: LimitsConfig{
+			MaxSessions:        100,
+			MaxSessionsPerIP:   5,
+			ConnRatePerMinute:  10,
+			ConnBurst:          5,
+			IdleTimeout:        Duration(10 * time.Minute),
+			IdleWarning:        Duration(time.Minute),
+			MaxSessionDuration: Duration(time.Hour),
+			HandshakeTimeout:   Duration(time.Minute),
+		},
+		Access: AccessConfig{
+			BanFile:           "tuiserver_bans.json",
+			BanThreshold:      10,
+			BanWindow:         Duration(10 * time.Minute),
+			BanDuration:       Duration(24 * time.Hour),
+			InstantDisconnect: Duration(2 * time.Second),
+		},
+		Admin: AdminConfig{
+			ConsoleUser: "admin",
+		},
+		Web: WebConfig{
+			Terminal: true,
+		},
+		Finger: FingerConfig{
+			MaxConnections:    20,
+			ConnRatePerMinute: 30,
+			ConnBurst:         10,
+		},
+		Gemini: GeminiConfig{
+			Hostname:          "localhost",
+			CertFile:          "tuiserver_gemini.crt",
+			KeyFile:           "tuiserver_gemini.key",
+			MaxConnections:    20,
+			ConnRatePerMinute: 60,
+			ConnBurst:         20,
+		},
+		Gopher: GopherConfig{
+			Hostname:          "localhost",
+			MaxConnections:    20,
+			ConnRatePerMinute: 60,
+			ConnBurst:         20,
+		},
+		Analytics: AnalyticsConfig{
+			File: "tuiserver_analytics.db",
+		},
+		Recording: RecordingConfig{
+			SampleRate: 1,
+			MaxAge:     Duration(30 * 24 * time.Hour),
+			MaxFiles:   1000,
+		},
+		Visitors: VisitorsConfig{
+			File: "tuiserver_visitors.db",
+		},
+		Private: PrivateConfig{
+			InviteFile: "tuiserver_invites.db",
+		},
+		Proxy: ProxyConfig{
+			HeaderTimeout: Duration(5 * time.Second),
+		},
+		Log: LogConfig{
+			File:       defaultLogPath,
+			Format:     "text",
+			Level:      "info",
+			Stdout:     true,
+			MaxSizeMB:  50,
+			MaxBackups: 10,
+			MaxAge:     Duration(30 * 24 * time.Hour),
+		},
+		Features: FeaturesConfig{
+			WelcomeScreen: true,
+			Mouse:         true,
+		},
+		Control: ControlConfig{
+			Socket: "tuiserver.sock",
+		},
+		ShutdownTimeout:     Duration(30 * time.Second),
+		UpgradeDrainTimeout: Duration(time.Hour),
+	}
+}
+
+// LoadConfig returns DefaultConfig overridden by the config file at path
+// (skipped when empty) and then by environment variables from lookupEnv.
+func LoadConfig(path string, lookupEnv func(string) (string, bool)) (Config, error) {
+	config := DefaultConfig()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return config, fmt.Errorf("failed to read config file: %w", err)
+		}
+		if err := json.Unmarshal(data, &config); err != nil {
+			return config, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
+	if lookupEnv != nil {
+		if err := applyEnv(reflect.ValueOf(&config).Elem(), EnvPrefix, lookupEnv); err != nil {
+			return config, err
+		}
+	}
+
+	return config, nil
+}
+
+// applyEnv walks the struct fields and sets those with a matching variable
+func applyEnv(v reflect.Value, prefix string, lookupEnv func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+		name := prefix + strings.ToUpper(key)
+		fv := v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnv(fv, name+"_", lookupEnv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := lookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setField(fv, raw); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setField(fv reflect.Value, raw string) error {
+	if u, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", fv.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		fv.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
+
+// ResolvePaths makes relative file paths absolute. Bare log and state file
+// names are placed in StateDir, which defaults to the first directory in
+// $STATE_DIRECTORY (set by systemd's StateDirectory=) or else the current
+// working directory. Any other relative path is taken relative to the
+// current working directory.
+func (c *Config) ResolvePaths() {
+	c.StateDir = resolveStateDir(c.StateDir, os.LookupEnv)
+	c.Log.File = resolvePath(c.Log.File, c.StateDir)
+	c.Access.BanFile = resolvePath(c.Access.BanFile, c.StateDir)
+	c.Analytics.File = resolvePath(c.Analytics.File, c.StateDir)
+	c.Recording.Dir = resolvePath(c.Recording.Dir, c.StateDir)
+	c.Visitors.File = resolvePath(c.Visitors.File, c.StateDir)
+	c.Private.InviteFile = resolvePath(c.Private.InviteFile, c.StateDir)
+	c.Gemini.CertFile = resolvePath(c.Gemini.CertFile, c.StateDir)
+	c.Gemini.KeyFile = resolvePath(c.Gemini.KeyFile, c.StateDir)
+	c.Control.Socket = resolvePath(c.Control.Socket, c.StateDir)
+	c.ContentPath = resolvePath(c.ContentPath, "")
+	c.Admin.AuthorizedKeys = resolvePath(c.Admin.AuthorizedKeys, "")
+	c.Private.AuthorizedKeys = resolvePath(c.Private.AuthorizedKeys, "")
+	c.Templates.DataFile = resolvePath(c.Templates.DataFile, "")
+	for i, key := range c.HostKeys {
+		c.HostKeys[i] = resolvePath(key, "")
+	}
+}
+
+// resolveStateDir returns dir made absolute, or the directory state files
+// default to when it is empty
+func resolveStateDir(dir string, lookupEnv func(string) (string, bool)) string {
+	if dir == "" {
+		// systemd passes every StateDirectory= entry, colon separated
+		if dirs, ok := lookupEnv("STATE_DIRECTORY"); ok {
+			dir, _, _ = strings.Cut(dirs, ":")
+		}
+	}
+	if dir == "" {
+		dir = "."
+	}
+	return resolvePath(dir, "")
+}
+
+// resolvePath makes path absolute. A bare file name is placed in dir when
+// dir is set, anything else is taken relative to the working directory.
+func resolvePath(path, dir string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	if dir != "" && !strings.Contains(path, "/") && !strings.Contains(path, "\\") {
+		return filepath.Join(dir, path)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err == nil {
+		return absPath
+	}
+	return path
+}
+
+// WriteJSON prints the configuration in config file format
+func (c Config) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c)
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigLayers(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(file, []byte(`{
+		"listen_addr": ":22",
+		"limits": {"max_sessions": 7, "idle_timeout": "3m"},
+		"access": {"deny": ["10.0.0.0/8"]}
+	}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		check func(c Config) bool
+	}{
+		{"defaults kept", nil, func(c Config) bool {
+			return c.Limits.MaxSessionsPerIP == DefaultConfig().Limits.MaxSessionsPerIP
+		}},
+		{"file over defaults", nil, func(c Config) bool {
+			return c.Limits.MaxSessions == 7 && c.Limits.IdleTimeout == Duration(3*time.Minute)
+		}},
+		{"single address", nil, func(c Config) bool {
+			return reflect.DeepEqual(c.ListenAddrs, Addresses{":22"})
+		}},
+		{"env over file", map[string]string{"TUISERVER_LIMITS_MAX_SESSIONS": "9"}, func(c Config) bool {
+			return c.Limits.MaxSessions == 9
+		}},
+		{"env duration", map[string]string{"TUISERVER_LIMITS_IDLE_TIMEOUT": "90s"}, func(c Config) bool {
+			return c.Limits.IdleTimeout == Duration(90*time.Second)
+		}},
+		{"env list", map[string]string{"TUISERVER_ACCESS_DENY": " 192.0.2.0/24, ,198.51.100.1"}, func(c Config) bool {
+			return reflect.DeepEqual(c.Access.Deny, []string{"192.0.2.0/24", "198.51.100.1"})
+		}},
+		{"env addresses", map[string]string{"TUISERVER_LISTEN_ADDR": ":2222,[::]:2222"}, func(c Config) bool {
+			return reflect.DeepEqual(c.ListenAddrs, Addresses{":2222", "[::]:2222"})
+		}},
+		{"env bool", map[string]string{"TUISERVER_FEATURES_MOUSE": "false"}, func(c Config) bool {
+			return !c.Features.Mouse
+		}},
+		{"env clears a string", map[string]string{"TUISERVER_CONTROL_SOCKET": ""}, func(c Config) bool {
+			return c.Control.Socket == ""
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := LoadConfig(file, lookup(tt.env))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(config) {
+				t.Errorf("unexpected config %+v", config)
+			}
+		})
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"limits": {"idle_timeout": "soon"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		env  map[string]string
+		want string
+	}{
+		{"missing file", filepath.Join(dir, "missing.json"), nil, "failed to read config file"},
+		{"bad file value", bad, nil, "failed to parse config file"},
+		{"bad env int", "", map[string]string{"TUISERVER_LIMITS_MAX_SESSIONS": "lots"}, "TUISERVER_LIMITS_MAX_SESSIONS"},
+		{"bad env duration", "", map[string]string{"TUISERVER_LIMITS_IDLE_TIMEOUT": "soon"}, "TUISERVER_LIMITS_IDLE_TIMEOUT"},
+		{"bad env bool", "", map[string]string{"TUISERVER_FEATURES_MOUSE": "maybe"}, "TUISERVER_FEATURES_MOUSE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(tt.path, lookup(tt.env))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// lookup serves environment variables from a map
+func lookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+}
+
+func TestResolveStateDir(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+		env  map[string]string
+		want string
+	}{
+		{"working directory", "", nil, wd},
+		{"systemd state directory", "", map[string]string{"STATE_DIRECTORY": "/var/lib/tuiserver"}, "/var/lib/tuiserver"},
+		{"first of several", "", map[string]string{"STATE_DIRECTORY": "/var/lib/a:/var/lib/b"}, "/var/lib/a"},
+		{"configured wins", "/srv/state", map[string]string{"STATE_DIRECTORY": "/var/lib/tuiserver"}, "/srv/state"},
+		{"configured relative", "state", nil, filepath.Join(wd, "state")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveStateDir(tt.dir, lookup(tt.env)); got != tt.want {
+				t.Errorf("resolveStateDir(%q) = %q, want %q", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolvePaths(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv("STATE_DIRECTORY", state)
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := DefaultConfig()
+	c.ContentPath = "content.json"
+	c.Visitors.File = ""
+	c.Private.InviteFile = "data/invites.db"
+	c.ResolvePaths()
+
+	tests := []struct {
+		name, got, want string
+	}{
+		{"state dir", c.StateDir, state},
+		{"log", c.Log.File, filepath.Join(state, "tuiserver_connections.log")},
+		{"bans", c.Access.BanFile, filepath.Join(state, "tuiserver_bans.json")},
+		{"analytics", c.Analytics.File, filepath.Join(state, "tuiserver_analytics.db")},
+		{"control socket", c.Control.Socket, filepath.Join(state, "tuiserver.sock")},
+		{"turned off", c.Visitors.File, ""},
+		{"relative path", c.Private.InviteFile, filepath.Join(wd, "data/invites.db")},
+		{"content", c.ContentPath, filepath.Join(wd, "content.json")},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/tui"
+)
+
+// runConsole shows an owner the live sessions, with actions to disconnect
+// them, ban their address or message them
+func (srv *Server) runConsole(t terminal) {
+	logger := t.logger.With("term", t.term, "size", formatSize(t.width, t.height))
+	logger.Info("admin console opened")
+
+	actions := tui.ConsoleActions{
+		List: func() []tui.ConsoleSession {
+			var sessions []tui.ConsoleSession
+			for _, info := range srv.sessions.list() {
+				sessions = append(sessions, tui.ConsoleSession(info))
+			}
+			return sessions
+		},
+		Kick: func(id string) error {
+			if !srv.sessions.send(id, tui.KickMsg{}) {
+				return errors.New("session has ended")
+			}
+			logger.Info("console: session disconnected", "target", id)
+			return nil
+		},
+		Ban: func(ip string) error {
+			srv.access.ban(ip, "manual", time.Duration(srv.config().Access.BanDuration))
+			kicked := srv.sessions.sendIP(ip, tui.KickMsg{})
+			logger.Info("console: address banned", "target_ip", ip, "disconnected", kicked)
+			return nil
+		},
+		Message: func(id, text string) error {
+			if !srv.sessions.send(id, tui.NoticeMsg(fmt.Sprintf("From the owner: %s", text))) {
+				return errors.New("session has ended")
+			}
+			logger.Info("console: message sent", "target", id, "message", text)
+			return nil
+		},
+		Broadcast: func(text string) error {
+			srv.broadcast(text)
+			return nil
+		},
+		Maintenance: func(text string) error {
+			srv.setMaintenance(text)
+			return nil
+		},
+	}
+
+	fmt.Fprint(t.rw, "\033[2J\033[H\033[?25l")
+	p := tea.NewProgram(tui.NewConsoleModel(actions, t.width, t.height),
+		tea.WithAltScreen(),
+		tea.WithInput(t.rw),
+		tea.WithOutput(t.rw),
+		tea.WithContext(t.ctx),
+		tea.WithoutSignalHandler(),
+	)
+	go forwardResizes(t, p, nil)
+
+	_, err := p.Run()
+	if !errors.Is(err, tea.ErrProgramKilled) {
+		fmt.Fprint(t.rw, "\033[?25h")
+	}
+	if err != nil && !errors.Is(err, tea.ErrProgramKilled) {
+		logger.Error("console error", "error", err)
+	}
+	logger.Info("admin console closed")
+}
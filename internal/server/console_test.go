@@ -0,0 +1,78 @@
+package server
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	ssh "github.com/charmbracelet/ssh"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+// syncBuffer collects log output written from several goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// captureLogs sends the default logger to a buffer for the rest of the test
+func captureLogs(t *testing.T) *syncBuffer {
+	logs := &syncBuffer{}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return logs
+}
+
+// Connecting as the console user with the owner's public key but without
+// its private key must get the portfolio, not the console with its kick,
+// ban and broadcast actions.
+func TestConsoleNeedsSignedOwnerKey(t *testing.T) {
+	dir := t.TempDir()
+	owner := writeKey(t, dir, "owner")
+	config := testConfig()
+	config.Features.WelcomeScreen = false
+	srv := newTestServer(t, config)
+	srv.portfolio = models.DefaultPortfolio()
+	srv.setOwners([]ssh.PublicKey{owner.PublicKey()})
+	addr := startSSH(t, srv, nil)
+
+	tests := []struct {
+		name     string
+		identity string
+		console  bool
+	}{
+		{"private key", filepath.Join(dir, "owner"), true},
+		{"public key only", filepath.Join(dir, "owner.pub"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			if out, err := openSSH(t, addr, config.Admin.ConsoleUser, tt.identity, "q"); err != nil {
+				t.Fatalf("ssh failed: %v\n%s", err, out)
+			}
+			if got := strings.Contains(logs.String(), "admin console opened"); got != tt.console {
+				t.Errorf("console opened = %v, want %v\n%s", got, tt.console, logs)
+			}
+			if got := strings.Contains(logs.String(), "terminal attached"); got == tt.console {
+				t.Errorf("portfolio shown = %v, want %v\n%s", got, !tt.console, logs)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/tui"
+)
+
+// content returns the full portfolio, hidden sections included
+func (srv *Server) content() models.Portfolio {
+	p, _ := srv.contentRevision()
+	return p
+}
+
+// contentRevision returns the full portfolio with its revision number in
+// the visitor store, 0 when visitors are not tracked
+func (srv *Server) contentRevision() (models.Portfolio, uint64) {
+	srv.contentMu.RLock()
+	defer srv.contentMu.RUnlock()
+	return srv.portfolio, srv.revision
+}
+
+// publicContent returns the portfolio shown to visitors without an
+// audience of their own or private access, e.g. over the web or finger,
+// with templates rendered without a visitor
+func (srv *Server) publicContent() models.Portfolio {
+	return srv.content().Visible().Public().ForAudience("").Render(srv.renderLine(nil))
+}
+
+// saveContent writes content edited in admin mode to the content file and
+// serves it to every session started from now on
+func (srv *Server) saveContent(p models.Portfolio) error {
+	path := srv.config().ContentPath
+	if path == "" {
+		return errors.New("no content file configured, set content_path")
+	}
+	if err := models.SavePortfolio(path, p); err != nil {
+		return err
+	}
+	srv.setContent(p)
+	return nil
+}
+
+// reloadContent reads the content file again, after it was edited outside
+// the server. Running sessions keep the version they started with.
+func (srv *Server) reloadContent() (models.Portfolio, error) {
+	p, err := models.LoadPortfolio(srv.config().ContentPath)
+	if err != nil {
+		return p, err
+	}
+	srv.setContent(p)
+	return p, nil
+}
+
+func (srv *Server) setContent(p models.Portfolio) {
+	revision := srv.addRevision(p)
+
+	srv.contentMu.Lock()
+	srv.portfolio = p
+	srv.revision = revision
+	srv.contentMu.Unlock()
+	tui.SetTheme(p.Theme)
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// how long a control request may take, reading and answering included
+const controlTimeout = 10 * time.Second
+
+// a control connection carries one request and its reply, each a JSON
+// object on a line
+type controlRequest struct {
+	Args []string `json:"args"`
+}
+
+type controlReply struct {
+	Output string `json:"output"`
+	Error  string `json:"error,omitempty"`
+}
+
+// startControl opens the control socket used by `tuiserver ctl`, or takes
+// over the one passed by the previous process
+func (srv *Server) startControl() error {
+	var ln net.Listener
+	if inherited := srv.activated["control"]; len(inherited) > 0 {
+		ln = inherited[0]
+		slog.Info("using inherited socket", "service", "control", "addr", ln.Addr().String())
+	} else if path := srv.config().Control.Socket; path != "" {
+		var err error
+		if ln, err = listenControl(path); err != nil {
+			return err
+		}
+	} else {
+		return nil
+	}
+
+	srv.control = ln
+	srv.bound = append(srv.bound, boundListener{"control", ln})
+	srv.protocolListeners = append(srv.protocolListeners, ln)
+	go srv.serveControl(ln)
+	return nil
+}
+
+// listenControl binds the control socket so only the user the server runs
+// as can connect. A socket left behind by a server that died is replaced,
+// one that still answers belongs to a running server and is an error.
+func listenControl(path string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("control socket %s is in use by another server", path)
+	}
+	if info, err := os.Lstat(path); err == nil && info.Mode()&fs.ModeSocket != 0 {
+		os.Remove(path)
+	}
+
+	ln, err := listenPrivate(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on control socket %s: %w", path, err)
+	}
+	return ln, nil
+}
+
+func (srv *Server) serveControl(ln net.Listener) {
+	slog.Info("control socket listening", "path", ln.Addr().String())
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			slog.Warn("control accept failed", "error", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		go srv.handleControl(conn)
+	}
+}
+
+// handleControl answers one request. It counts as a running session so a
+// shutdown it triggers waits for the reply to go out.
+func (srv *Server) handleControl(conn net.Conn) {
+	defer conn.Close()
+	if !srv.startStream() {
+		return
+	}
+	defer srv.streamSessions.Done()
+
+	conn.SetDeadline(time.Now().Add(controlTimeout))
+
+	var req controlRequest
+	var reply controlReply
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		reply.Error = fmt.Sprintf("invalid request: %v", err)
+	} else {
+		slog.Info("control command", "command", strings.Join(req.Args, " "))
+		var out bytes.Buffer
+		if err := srv.runAdminCommand(&out, req.Args, true); err != nil {
+			reply.Error = err.Error()
+		}
+		reply.Output = out.String()
+	}
+
+	if err := json.NewEncoder(conn).Encode(reply); err != nil {
+		slog.Warn("control reply failed", "error", err)
+	}
+}
+
+// Control runs a command on the server listening on the control socket at
+// path, writing its output to w
+func Control(path string, args []string, w io.Writer) error {
+	if path == "" {
+		return errors.New("no control socket configured, set control.socket")
+	}
+	conn, err := net.DialTimeout("unix", path, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("is the server running? %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(controlTimeout))
+
+	if err := json.NewEncoder(conn).Encode(controlRequest{Args: args}); err != nil {
+		return err
+	}
+	var reply controlReply
+	if err := json.NewDecoder(conn).Decode(&reply); err != nil {
+		return fmt.Errorf("no reply from the server: %w", err)
+	}
+
+	io.WriteString(w, reply.Output)
+	if reply.Error != "" {
+		return errors.New(reply.Error)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+//go:build !unix
+
+package server
+
+import (
+	"net"
+	"os"
+)
+
+// listenPrivate binds a unix socket that only the user the server runs as
+// can connect to, as far as the file mode is honoured here
+func listenPrivate(path string) (net.Listener, error) {
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package server
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenPrivate binds a unix socket that only the user the server runs as
+// can connect to. The umask applies while the socket file is created, a
+// chmod afterwards would leave a moment in which anyone can connect. Files
+// created elsewhere in the meantime only come out more private.
+func listenPrivate(path string) (net.Listener, error) {
+	old := syscall.Umask(0177)
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,24 @@
+//go:build unix
+
+package server
+
+import (
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// The umask that keeps the control socket private is put back afterwards
+func TestListenPrivateRestoresUmask(t *testing.T) {
+	before := syscall.Umask(0022)
+	defer syscall.Umask(before)
+
+	ln, err := listenPrivate(filepath.Join(t.TempDir(), "tuiserver.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.Close()
+	if after := syscall.Umask(0022); after != 0022 {
+		t.Errorf("umask after listenPrivate = %#o, want 022", after)
+	}
+}
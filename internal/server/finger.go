@@ -0,0 +1,84 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/render"
+)
+
+// longest finger query accepted, RFC 1288 queries are a user name at most
+const fingerQueryLimit = 256
+
+// startFinger binds the finger listener when one is configured
+func (srv *Server) startFinger() error {
+	listeners, err := srv.listen("finger", srv.config().Finger.ListenAddr)
+	if err != nil {
+		return err
+	}
+	srv.protocolListeners = append(srv.protocolListeners, listeners...)
+
+	limits := newLimiter(LimitsConfig{
+		MaxSessions:       srv.config().Finger.MaxConnections,
+		ConnRatePerMinute: srv.config().Finger.ConnRatePerMinute,
+		ConnBurst:         srv.config().Finger.ConnBurst,
+	})
+	for _, ln := range listeners {
+		go srv.serveProtocol(ln, "finger", limits, srv.handleFinger)
+	}
+	return nil
+}
+
+// handleFinger answers one RFC 1288 query. `finger @host` and `finger
+// name@host` get the whole portfolio as a .plan, `finger section@host`
+// gets that section alone.
+func (srv *Server) handleFinger(conn net.Conn, logger *slog.Logger) {
+	query, err := readRequestLine(conn, fingerQueryLimit)
+	if err != nil {
+		logger.Info("finger query failed", "error", err)
+		return
+	}
+
+	// drop the verbose switch, it makes no difference here
+	user := strings.TrimSpace(query)
+	if user == "/W" || strings.HasPrefix(user, "/W ") {
+		user = strings.TrimSpace(strings.TrimPrefix(user, "/W"))
+	}
+	logger.Info("finger query", "query", user)
+
+	// user@host asks us to forward the query, which is refused as RFC 1288
+	// recommends
+	if strings.Contains(user, "@") {
+		io.WriteString(conn, "finger: forwarding service denied\r\n")
+		return
+	}
+
+	io.WriteString(conn, crlf(srv.fingerResponse(user)))
+}
+
+func (srv *Server) fingerResponse(user string) string {
+	portfolio := srv.publicContent()
+	if sec, ok := render.FindSection(portfolio, user); ok {
+		return render.PlainSection(sec)
+	}
+
+	login := user
+	if login == "" {
+		login = portfolio.Title
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Login: %-32s Name: %s\n", login, portfolio.Title)
+	b.WriteString("Plan:\n")
+	b.WriteString(render.Plain(portfolio))
+
+	var names []string
+	for _, sec := range portfolio.Sections {
+		names = append(names, render.Anchor(sec.Title))
+	}
+	fmt.Fprintf(&b, "\nfinger <section>@host for one section: %s\n", strings.Join(names, ", "))
+	return b.String()
+}
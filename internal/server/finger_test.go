@@ -0,0 +1,72 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFingerResponse(t *testing.T) {
+	srv := newTestServer(t, testConfig())
+	srv.portfolio = testContent()
+
+	tests := []struct {
+		name    string
+		user    string
+		want    []string
+		notWant []string
+	}{
+		{"whole plan", "", []string{"Login: Jane Doe", "Plan:", "ABOUT", "Hello", "finger <section>@host for one section: about"}, []string{"SECRET", "private.example.com"}},
+		{"any name", "jane", []string{"Login: jane", "Name: Jane Doe", "ABOUT"}, []string{"SECRET"}},
+		{"section by anchor", "about", []string{"ABOUT", "Blog: https://example.com/blog"}, []string{"Login:", "Plan:"}},
+		{"section ignores case", "ABOUT", []string{"ABOUT\n-----"}, []string{"Login:"}},
+		{"private section", "secret", []string{"Login: secret"}, []string{"private.example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := srv.fingerResponse(tt.user)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("response lacks %q\n%s", want, got)
+				}
+			}
+			for _, not := range tt.notWant {
+				if strings.Contains(got, not) {
+					t.Errorf("response contains %q\n%s", not, got)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleFinger(t *testing.T) {
+	srv := newTestServer(t, testConfig())
+	srv.portfolio = testContent()
+
+	tests := []struct {
+		name    string
+		query   string
+		prefix  string
+		notWant string
+	}{
+		{"plan", "\r\n", "Login: Jane Doe", ""},
+		{"section", "about\r\n", "ABOUT\r\n", "Login:"},
+		{"verbose switch", "/W about\r\n", "ABOUT\r\n", "Login:"},
+		{"verbose alone", "/W\r\n", "Login: Jane Doe", ""},
+		{"forwarding", "jane@other.example\r\n", "finger: forwarding service denied\r\n", "ABOUT"},
+		{"forwarding verbose", "/W @other.example\r\n", "finger: forwarding service denied\r\n", "ABOUT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := serve(t, srv.handleFinger, tt.query)
+			if !strings.HasPrefix(out, tt.prefix) {
+				t.Errorf("response starts %q, want %q", out[:min(len(out), 40)], tt.prefix)
+			}
+			if tt.notWant != "" && strings.Contains(out, tt.notWant) {
+				t.Errorf("response contains %q", tt.notWant)
+			}
+			if strings.Contains(strings.ReplaceAll(out, "\r\n", ""), "\n") {
+				t.Error("response has bare line feeds")
+			}
+		})
+	}
+}
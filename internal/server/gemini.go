@@ -0,0 +1,96 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/render"
+)
+
+// longest request URL allowed by the Gemini specification
+const geminiRequestLimit = 1024
+
+// startGemini binds the Gemini TLS listener when one is configured,
+// generating the certificate on first start
+func (srv *Server) startGemini() error {
+	config := srv.config().Gemini
+	listeners, err := srv.listen("gemini", config.ListenAddr)
+	if err != nil || len(listeners) == 0 {
+		return err
+	}
+	srv.protocolListeners = append(srv.protocolListeners, listeners...)
+
+	if err := ensureCertificate(config.CertFile, config.KeyFile, config.Hostname); err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load gemini certificate: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	limits := newLimiter(LimitsConfig{
+		MaxSessions:       config.MaxConnections,
+		ConnRatePerMinute: config.ConnRatePerMinute,
+		ConnBurst:         config.ConnBurst,
+	})
+	for _, ln := range listeners {
+		go srv.serveProtocol(tls.NewListener(ln, tlsConfig), "gemini", limits, srv.handleGemini)
+	}
+	return nil
+}
+
+// handleGemini answers one Gemini request: the index at / and a page per
+// section at /<anchor>
+func (srv *Server) handleGemini(conn net.Conn, logger *slog.Logger) {
+	line, err := readRequestLine(conn, geminiRequestLimit)
+	if err != nil {
+		logger.Info("gemini request failed", "error", err)
+		return
+	}
+
+	status, meta, body := srv.geminiResponse(line)
+	logger.Info("gemini request", "url", line, "status", status)
+
+	fmt.Fprintf(conn, "%d %s\r\n", status, meta)
+	io.WriteString(conn, body)
+}
+
+func (srv *Server) geminiResponse(request string) (status int, meta, body string) {
+	u, err := url.Parse(request)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return 59, "Bad request", ""
+	}
+	if u.Scheme != "gemini" || !srv.isGeminiHost(u.Hostname()) {
+		return 53, "Proxy request refused", ""
+	}
+
+	const gemtext = "text/gemini; charset=utf-8"
+	portfolio := srv.publicContent()
+	name := strings.Trim(u.Path, "/")
+	if name == "" {
+		return 20, gemtext, render.GemtextIndex(portfolio)
+	}
+	if sec, ok := render.FindSection(portfolio, name); ok {
+		return 20, gemtext, render.GemtextSection(sec)
+	}
+	return 51, "Not found", ""
+}
+
+// isGeminiHost reports whether a request is addressed to this capsule.
+// Loopback names are always accepted so the capsule can be tried locally.
+func (srv *Server) isGeminiHost(host string) bool {
+	if strings.EqualFold(host, srv.config().Gemini.Hostname) || strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
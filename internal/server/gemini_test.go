@@ -0,0 +1,51 @@
+package server
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeminiResponse(t *testing.T) {
+	config := testConfig()
+	config.Gemini.Hostname = "capsule.example"
+	srv := newTestServer(t, config)
+	srv.portfolio = testContent()
+
+	tests := []struct {
+		name    string
+		request string
+		status  int
+		meta    string
+		body    string
+	}{
+		{"index", "gemini://capsule.example/", 20, "text/gemini; charset=utf-8", "=> /about About"},
+		{"index without slash", "gemini://capsule.example", 20, "text/gemini; charset=utf-8", "# Jane Doe"},
+		{"host ignores case", "gemini://CAPSULE.example/", 20, "text/gemini; charset=utf-8", "# Jane Doe"},
+		{"with port", "gemini://capsule.example:1965/about", 20, "text/gemini; charset=utf-8", "=> https://example.com/blog"},
+		{"section", "gemini://capsule.example/about", 20, "text/gemini; charset=utf-8", "# About"},
+		{"localhost", "gemini://localhost/about", 20, "text/gemini; charset=utf-8", "# About"},
+		{"loopback", "gemini://127.0.0.1/", 20, "text/gemini; charset=utf-8", "# Jane Doe"},
+		{"loopback v6", "gemini://[::1]/", 20, "text/gemini; charset=utf-8", "# Jane Doe"},
+		{"private section", "gemini://capsule.example/secret", 51, "Not found", ""},
+		{"unknown section", "gemini://capsule.example/nope", 51, "Not found", ""},
+		{"other host", "gemini://other.example/", 53, "Proxy request refused", ""},
+		{"other scheme", "https://capsule.example/", 53, "Proxy request refused", ""},
+		{"relative", "/about", 59, "Bad request", ""},
+		{"no host", "gemini:///about", 59, "Bad request", ""},
+		{"invalid", "gemini://capsule.example/%zz", 59, "Bad request", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, meta, body := srv.geminiResponse(tt.request)
+			if status != tt.status || meta != tt.meta {
+				t.Errorf("geminiResponse(%q) = %d %q, want %d %q", tt.request, status, meta, tt.status, tt.meta)
+			}
+			if !strings.Contains(body, tt.body) {
+				t.Errorf("body lacks %q\n%s", tt.body, body)
+			}
+			if strings.Contains(body, "private.example.com") {
+				t.Error("private content served")
+			}
+		})
+	}
+}
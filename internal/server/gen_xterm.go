@@ -0,0 +1,116 @@
+//go:build ignore
+
+// gen_xterm downloads the pinned xterm.js packages from the npm registry,
+// checks them against the registry's integrity hashes and writes the files
+// the web terminal needs to the xterm directory.
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// packages and the files taken from each
+var packages = []struct {
+	name, version string
+	files         []string
+}{
+	{"xterm", "5.3.0", []string{"css/xterm.css", "lib/xterm.js", "LICENSE"}},
+	{"xterm-addon-fit", "0.8.0", []string{"lib/xterm-addon-fit.js"}},
+}
+
+func main() {
+	for _, p := range packages {
+		if err := fetch(p.name, p.version, p.files); err != nil {
+			log.Fatalf("%s@%s: %v", p.name, p.version, err)
+		}
+	}
+}
+
+func fetch(name, version string, files []string) error {
+	var meta struct {
+		Dist struct {
+			Tarball   string `json:"tarball"`
+			Integrity string `json:"integrity"`
+		} `json:"dist"`
+	}
+	data, err := get(fmt.Sprintf("https://registry.npmjs.org/%s/%s", name, version))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return err
+	}
+
+	tarball, err := get(meta.Dist.Tarball)
+	if err != nil {
+		return err
+	}
+	sum := sha512.Sum512(tarball)
+	if got := "sha512-" + base64.StdEncoding.EncodeToString(sum[:]); got != meta.Dist.Integrity {
+		return fmt.Errorf("tarball hash %s does not match %s", got, meta.Dist.Integrity)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(tarball))
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(gz)
+	wanted := map[string]bool{}
+	for _, f := range files {
+		wanted["package/"+f] = true
+	}
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !wanted[h.Name] {
+			continue
+		}
+		delete(wanted, h.Name)
+
+		out := filepath.Base(h.Name)
+		if out == "LICENSE" {
+			out = name + ".LICENSE"
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join("xterm", out), content, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("xterm/%s from %s@%s\n", out, name, version)
+	}
+	for f := range wanted {
+		return fmt.Errorf("%s not in the package", strings.TrimPrefix(f, "package/"))
+	}
+	return nil
+}
+
+func get(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/render"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/tui"
+)
+
+// longest selector accepted, ours are short section names and URLs
+const gopherSelectorLimit = 1024
+
+// gopherRedirect is the page served for URL: selectors to clients that do
+// not open h items themselves
+var gopherRedirect = template.Must(template.New("redirect").Parse(`<!doctype html>
+<html>
+<head>
+<meta http-equiv="refresh" content="2;URL={{.}}">
+</head>
+<body>
+<p>You are leaving gopherspace for <a href="{{.}}">{{.}}</a>.</p>
+</body>
+</html>
+`))
+
+// startGopher binds the gopher listener when one is configured
+func (srv *Server) startGopher() error {
+	config := srv.config().Gopher
+	listeners, err := srv.listen("gopher", config.ListenAddr)
+	if err != nil {
+		return err
+	}
+	srv.protocolListeners = append(srv.protocolListeners, listeners...)
+
+	limits := newLimiter(LimitsConfig{
+		MaxSessions:       config.MaxConnections,
+		ConnRatePerMinute: config.ConnRatePerMinute,
+		ConnBurst:         config.ConnBurst,
+	})
+	for _, ln := range listeners {
+		go srv.serveProtocol(ln, "gopher", limits, srv.handleGopher)
+	}
+	return nil
+}
+
+// handleGopher answers one RFC 1436 request: the menu for the empty
+// selector, a text document for /<anchor> and a redirect page for URL:
+// selectors of links in the menu
+func (srv *Server) handleGopher(conn net.Conn, logger *slog.Logger) {
+	selector, err := readRequestLine(conn, gopherSelectorLimit)
+	if err != nil {
+		logger.Info("gopher request failed", "error", err)
+		return
+	}
+
+	// gopher+ clients append a tab and more fields, only the selector matters
+	selector, _, _ = strings.Cut(selector, "\t")
+	logger.Info("gopher request", "selector", selector)
+
+	portfolio := srv.publicContent()
+	if link, ok := strings.CutPrefix(strings.TrimPrefix(selector, "/"), "URL:"); ok {
+		// only links we list, the server is no redirector for anything else
+		if !gopherLink(portfolio, link) {
+			fmt.Fprintf(conn, "3'%s' not found\t\terror.host\t1\r\n.\r\n", selector)
+			return
+		}
+		gopherRedirect.Execute(conn, link)
+		return
+	}
+
+	name := strings.Trim(selector, "/")
+	if name == "" {
+		io.WriteString(conn, render.GopherMenu(portfolio, srv.config().Gopher.Hostname, srv.gopherPort()))
+		return
+	}
+	if sec, ok := render.FindSection(portfolio, name); ok {
+		io.WriteString(conn, render.GopherText(render.PlainSection(sec)))
+		return
+	}
+	fmt.Fprintf(conn, "3'%s' not found\t\terror.host\t1\r\n.\r\n", selector)
+}
+
+// gopherLink reports whether link is an http(s) link of the portfolio, one
+// the menu offers as URL: item
+func gopherLink(p models.Portfolio, link string) bool {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false
+	}
+	for _, sec := range p.Sections {
+		if slices.Contains(tui.FindLinks(sec.Content), link) {
+			return true
+		}
+	}
+	return false
+}
+
+// gopherPort is the port menu items point to, the one we listen on
+func (srv *Server) gopherPort() string {
+	_, port, err := net.SplitHostPort(srv.config().Gopher.ListenAddr)
+	if err != nil || port == "" {
+		return "70"
+	}
+	return port
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+// testContent has a public section with a link and a private one with
+// another
+func testContent() models.Portfolio {
+	return models.Portfolio{
+		Title: "Jane Doe",
+		Sections: []models.Section{
+			{Title: "About", Content: []string{"Hello", ".dotted line", "Blog: https://example.com/blog"}},
+			{Title: "Secret", Content: []string{"https://private.example.com"}, Private: true},
+		},
+	}
+}
+
+// serve sends request to handler over a pipe and returns the response
+func serve(t *testing.T, handler func(net.Conn, *slog.Logger), request string) string {
+	t.Helper()
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		handler(server, slog.Default())
+	}()
+	defer client.Close()
+	go io.WriteString(client, request)
+	out, err := io.ReadAll(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestGopherRedirect(t *testing.T) {
+	srv := newTestServer(t, testConfig())
+	srv.portfolio = testContent()
+
+	tests := []struct {
+		name     string
+		selector string
+		redirect bool
+	}{
+		{"listed link", "URL:https://example.com/blog", true},
+		{"listed link with slash", "/URL:https://example.com/blog", true},
+		{"private link", "URL:https://private.example.com", false},
+		{"any other site", "URL:https://evil.example.net", false},
+		{"javascript", "URL:javascript:alert(1)", false},
+		{"listed link with other scheme", "URL:ftp://example.com/blog", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := serve(t, srv.handleGopher, tt.selector+"\r\n")
+			redirected := strings.Contains(out, `http-equiv="refresh"`)
+			if redirected != tt.redirect {
+				t.Errorf("redirected = %v, want %v\n%s", redirected, tt.redirect, out)
+			}
+			if !tt.redirect && !strings.HasPrefix(out, "3") {
+				t.Errorf("refused selector got %q, want a gopher error", out)
+			}
+		})
+	}
+}
+
+func TestHandleGopher(t *testing.T) {
+	config := testConfig()
+	config.Gopher.Hostname = "gopher.example"
+	config.Gopher.ListenAddr = ":7070"
+	srv := newTestServer(t, config)
+	srv.portfolio = testContent()
+
+	tests := []struct {
+		name     string
+		selector string
+		want     string
+	}{
+		{"menu", "\r\n", "0About\t/about\tgopher.example\t7070\r\n"},
+		{"menu links", "\r\n", "hhttps://example.com/blog\tURL:https://example.com/blog\tgopher.example\t7070\r\n"},
+		{"section", "/about\r\n", "ABOUT\r\n"},
+		{"section without slash", "about\r\n", "ABOUT\r\n"},
+		{"gopher+ fields", "/about\t+\r\n", "ABOUT\r\n"},
+		{"indented dot line", "/about\r\n", "\r\n  .dotted line\r\n"},
+		{"private section", "/secret\r\n", "3'/secret' not found"},
+		{"unknown", "/nope\r\n", "3'/nope' not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := serve(t, srv.handleGopher, tt.selector)
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("response lacks %q\n%s", tt.want, out)
+			}
+			if !strings.HasSuffix(out, ".\r\n") {
+				t.Errorf("response not terminated\n%s", out)
+			}
+			if strings.Contains(out, "private.example.com") {
+				t.Error("private content served")
+			}
+		})
+	}
+}
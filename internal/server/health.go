@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// Version is the release version, set at build time with
+// -ldflags "-X github.com/cankurttekin/sh.kurttekin.com/internal/server.Version=v1.2.3"
+var Version = "dev"
+
+// healthCheck is one readiness condition
+type healthCheck struct {
+	name string
+	err  error
+}
+
+// readiness reports every readiness condition. The server is ready when
+// the SSH listener is bound, the content is valid, the host keys loaded
+// and it is not shutting down.
+func (srv *Server) readiness() []healthCheck {
+	var checks []healthCheck
+
+	var err error
+	if !srv.listening.Load() {
+		err = fmt.Errorf("not bound")
+	}
+	checks = append(checks, healthCheck{"ssh_listener", err})
+
+	checks = append(checks, healthCheck{"content", srv.content().Validate()})
+
+	err = nil
+	if !srv.hostKeysLoaded.Load() {
+		err = fmt.Errorf("not loaded")
+	}
+	checks = append(checks, healthCheck{"host_keys", err})
+
+	err = nil
+	if srv.draining.Load() {
+		err = fmt.Errorf("shutting down")
+	}
+	checks = append(checks, healthCheck{"shutdown", err})
+
+	return checks
+}
+
+// handleHealthz reports that the process is alive
+func (srv *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReadyz lists the readiness checks, answering 503 if any fails
+func (srv *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	checks := srv.readiness()
+
+	status := http.StatusOK
+	for _, c := range checks {
+		if c.err != nil {
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	for _, c := range checks {
+		if c.err != nil {
+			fmt.Fprintf(w, "[-] %s: %v\n", c.name, c.err)
+		} else {
+			fmt.Fprintf(w, "[+] %s ok\n", c.name)
+		}
+	}
+}
+
+// handleVersion describes the running build
+func (srv *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
+	info := map[string]string{
+		"version":    Version,
+		"go_version": runtime.Version(),
+		"started":    srv.started.Format(time.RFC3339),
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range build.Settings {
+			if s.Key == "vcs.revision" {
+				info["revision"] = s.Value
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
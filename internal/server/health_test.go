@@ -0,0 +1,46 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+func TestReadyz(t *testing.T) {
+	tests := []struct {
+		name      string
+		listening bool
+		keys      bool
+		draining  bool
+		content   models.Portfolio
+		status    int
+		want      string
+	}{
+		{"ready", true, true, false, models.DefaultPortfolio(), http.StatusOK, "[+] shutdown ok"},
+		{"not listening yet", false, true, false, models.DefaultPortfolio(), http.StatusServiceUnavailable, "[-] ssh_listener: not bound"},
+		{"host keys not loaded", true, false, false, models.DefaultPortfolio(), http.StatusServiceUnavailable, "[-] host_keys: not loaded"},
+		{"draining", true, true, true, models.DefaultPortfolio(), http.StatusServiceUnavailable, "[-] shutdown: shutting down"},
+		{"invalid content", true, true, false, models.Portfolio{}, http.StatusServiceUnavailable, "[-] content:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestServer(t, testConfig())
+			srv.portfolio = tt.content
+			srv.listening.Store(tt.listening)
+			srv.hostKeysLoaded.Store(tt.keys)
+			srv.draining.Store(tt.draining)
+
+			w := httptest.NewRecorder()
+			srv.handleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("body lacks %q\n%s", tt.want, w.Body)
+			}
+		})
+	}
+}
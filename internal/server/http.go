@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// handleHTTP registers an HTTP handler on addr. Features configured with
+// the same address share one listener.
+func (srv *Server) handleHTTP(addr, pattern string, handler http.Handler) {
+	if srv.httpMuxes == nil {
+		srv.httpMuxes = make(map[string]*http.ServeMux)
+	}
+	mux, ok := srv.httpMuxes[addr]
+	if !ok {
+		mux = http.NewServeMux()
+		srv.httpMuxes[addr] = mux
+	}
+	mux.Handle(pattern, handler)
+}
+
+// startHTTP starts one HTTP server per registered address. All addresses
+// are bound before any is served so a bind failure stops the start.
+func (srv *Server) startHTTP() error {
+	listeners := make(map[string]net.Listener)
+	for addr := range srv.httpMuxes {
+		ln, err := srv.listenHTTP(addr)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return err
+		}
+		listeners[addr] = ln
+	}
+	// inherited sockets for addresses no longer configured
+	for _, ln := range srv.activated["http"] {
+		slog.Info("closing inherited HTTP socket that is no longer configured", "addr", ln.Addr().String())
+		ln.Close()
+	}
+	delete(srv.activated, "http")
+
+	for addr, mux := range srv.httpMuxes {
+		hs := &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		srv.httpServers = append(srv.httpServers, hs)
+
+		go func(ln net.Listener) {
+			slog.Info("HTTP listener started", "addr", hs.Addr)
+			if err := hs.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("HTTP listener stopped", "addr", hs.Addr, "error", err)
+			}
+		}(listeners[addr])
+	}
+	return nil
+}
+
+// shutdownHTTP stops the HTTP servers, letting in-flight requests finish
+func (srv *Server) shutdownHTTP(ctx context.Context) {
+	for _, hs := range srv.httpServers {
+		if err := hs.Shutdown(ctx); err != nil {
+			slog.Warn("HTTP listener shutdown", "addr", hs.Addr, "error", err)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// reasons a session is turned away before the TUI starts
+const (
+	rejectServerFull = "server_full"
+	rejectIPFull     = "ip_full"
+	rejectRateLimit  = "rate_limited"
+)
+
+// polite messages written to rejected sessions
+var rejectMessages = map[string]string{
+	rejectServerFull: "Sorry, the server is busy right now. Please try again in a few minutes.",
+	rejectIPFull:     "You already have the maximum number of sessions open from your address.",
+	rejectRateLimit:  "Too many connections from your address, slow down and try again shortly.",
+}
+
+// limiter enforces session caps and per-IP new-session rates. SSH
+// connections are limited as well, before their handshake, so clients
+// that never get to a session cannot use up the server either.
+type limiter struct {
+	config LimitsConfig
+
+	mu         sync.Mutex
+	active     int
+	perIP      map[string]int
+	conns      int
+	connsPerIP map[string]int
+	buckets    map[string]*ipBucket
+	lastPrune  time.Time
+}
+
+type ipBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newLimiter(config LimitsConfig) *limiter {
+	return &limiter{
+		config:     config,
+		perIP:      make(map[string]int),
+		connsPerIP: make(map[string]int),
+		buckets:    make(map[string]*ipBucket),
+	}
+}
+
+// reconfigure applies new limits. Running sessions keep their slots, rate
+// buckets start over at the new rate.
+func (l *limiter) reconfigure(config LimitsConfig) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.config = config
+	l.buckets = make(map[string]*ipBucket)
+}
+
+// acquire takes a token from ip's rate bucket and reserves a session slot,
+// for transports whose connections are sessions. On success it returns a
+// release func that must be called when the session ends; otherwise it
+// returns the rejection reason.
+func (l *limiter) acquire(ip string) (release func(), reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.allowLocked(ip) {
+		return nil, rejectRateLimit
+	}
+	return l.reserveLocked(&l.active, l.perIP, ip)
+}
+
+// connect takes a token from ip's rate bucket and reserves a connection
+// slot, before an SSH handshake. Sessions on the connection reserve
+// session slots with session.
+func (l *limiter) connect(ip string) (release func(), reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.allowLocked(ip) {
+		return nil, rejectRateLimit
+	}
+	return l.reserveLocked(&l.conns, l.connsPerIP, ip)
+}
+
+// session reserves a session slot on a connection that was let in by
+// connect
+func (l *limiter) session(ip string) (release func(), reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.reserveLocked(&l.active, l.perIP, ip)
+}
+
+// allowLocked takes a token from ip's rate bucket
+func (l *limiter) allowLocked(ip string) bool {
+	now := time.Now()
+	l.prune(now)
+
+	if l.config.ConnRatePerMinute <= 0 {
+		return true
+	}
+	b, ok := l.buckets[ip]
+	if !ok {
+		every := rate.Every(time.Minute / time.Duration(l.config.ConnRatePerMinute))
+		b = &ipBucket{limiter: rate.NewLimiter(every, max(l.config.ConnBurst, 1))}
+		l.buckets[ip] = b
+	}
+	b.lastSeen = now
+	return b.limiter.AllowN(now, 1)
+}
+
+// reserveLocked counts ip in total and perIP unless that passes the caps
+func (l *limiter) reserveLocked(total *int, perIP map[string]int, ip string) (release func(), reason string) {
+	if l.config.MaxSessions > 0 && *total >= l.config.MaxSessions {
+		return nil, rejectServerFull
+	}
+	if l.config.MaxSessionsPerIP > 0 && perIP[ip] >= l.config.MaxSessionsPerIP {
+		return nil, rejectIPFull
+	}
+
+	*total++
+	perIP[ip]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			*total--
+			if perIP[ip]--; perIP[ip] <= 0 {
+				delete(perIP, ip)
+			}
+		})
+	}, ""
+}
+
+// prune drops token buckets that have been idle long enough to refill
+func (l *limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < time.Minute {
+		return
+	}
+	l.lastPrune = now
+	for ip, b := range l.buckets {
+		if now.Sub(b.lastSeen) > 10*time.Minute {
+			delete(l.buckets, ip)
+		}
+	}
+}
+
+// remoteIP returns the host part of a remote address
+func remoteIP(addr net.Addr) string {
+	if tcp, ok := addr.(*net.TCPAddr); ok {
+		return tcp.IP.String()
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	ssh "github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// readBanner returns the server's version line, "" when the server closed
+// the connection without one
+func readBanner(t *testing.T, addr string) (net.Conn, string) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, _ := bufio.NewReader(conn).ReadString('\n')
+	return conn, strings.TrimSpace(line)
+}
+
+func TestConnectionsLimitedBeforeHandshake(t *testing.T) {
+	config := testConfig()
+	config.Limits.ConnRatePerMinute = 1
+	config.Limits.ConnBurst = 1
+	srv := newTestServer(t, config)
+	addr := startSSH(t, srv, func(s ssh.Session) {})
+
+	if _, banner := readBanner(t, addr); !strings.HasPrefix(banner, "SSH-2.0-") {
+		t.Fatalf("first connection got %q, want the SSH banner", banner)
+	}
+	if _, banner := readBanner(t, addr); banner != rejectMessages[rejectRateLimit] {
+		t.Errorf("rate limited connection got %q, want the rate limit message", banner)
+	}
+}
+
+// Connections over the caps are told why before they are closed
+func TestConnectionsOverCapsGetMessage(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(*LimitsConfig)
+		reason string
+	}{
+		{"server full", func(c *LimitsConfig) { c.MaxSessions = 1 }, rejectServerFull},
+		{"address full", func(c *LimitsConfig) { c.MaxSessionsPerIP = 1 }, rejectIPFull},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			tt.config(&config.Limits)
+			srv := newTestServer(t, config)
+			addr := startSSH(t, srv, func(s ssh.Session) {})
+
+			if _, banner := readBanner(t, addr); !strings.HasPrefix(banner, "SSH-2.0-") {
+				t.Fatalf("first connection got %q, want the SSH banner", banner)
+			}
+			conn, banner := readBanner(t, addr)
+			if banner != rejectMessages[tt.reason] {
+				t.Errorf("second connection got %q, want %q", banner, rejectMessages[tt.reason])
+			}
+			// and then nothing, the connection is closed
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			if n, err := conn.Read(make([]byte, 64)); n != 0 || err == nil {
+				t.Errorf("read %d bytes after the message, err %v", n, err)
+			}
+		})
+	}
+}
+
+func TestHandshakeTimeout(t *testing.T) {
+	config := testConfig()
+	config.Limits.HandshakeTimeout = Duration(200 * time.Millisecond)
+	srv := newTestServer(t, config)
+	addr := startSSH(t, srv, nil)
+
+	conn, banner := readBanner(t, addr)
+	if !strings.HasPrefix(banner, "SSH-2.0-") {
+		t.Fatalf("got %q, want the SSH banner", banner)
+	}
+	// the client never answers, the server must give up on it
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 4096)
+	for {
+		if _, err := conn.Read(buf); err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				t.Fatal("connection still open after the handshake timeout")
+			}
+			break
+		}
+	}
+}
+
+// The handshake timeout ends with the handshake, sessions run on
+func TestHandshakeTimeoutLifted(t *testing.T) {
+	config := testConfig()
+	config.Limits.HandshakeTimeout = Duration(200 * time.Millisecond)
+	srv := newTestServer(t, config)
+	done := make(chan error, 1)
+	addr := startSSH(t, srv, func(s ssh.Session) {
+		handshakeDone(s)
+		time.Sleep(500 * time.Millisecond)
+		_, err := s.Write([]byte("still here\n"))
+		done <- err
+	})
+
+	client, err := gossh.Dial("tcp", addr, &gossh.ClientConfig{
+		User:            "visitor",
+		Auth:            []gossh.AuthMethod{gossh.PublicKeys(newSigner(t))},
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	out, err := session.Output("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != "still here\n" {
+		t.Errorf("output = %q", out)
+	}
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
+
+// Commands take a session slot like the TUI
+func TestCommandsTakeSessionSlots(t *testing.T) {
+	config := testConfig()
+	config.Limits.MaxSessions = 1
+	srv := newTestServer(t, config)
+	addr := startSSH(t, srv, nil)
+
+	hold, reason := srv.limiter.session("192.0.2.1")
+	if reason != "" {
+		t.Fatal(reason)
+	}
+	defer hold()
+
+	client, err := gossh.Dial("tcp", addr, &gossh.ClientConfig{
+		User:            "visitor",
+		Auth:            []gossh.AuthMethod{gossh.PublicKeys(newSigner(t))},
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	out, _ := session.CombinedOutput("help")
+	if !strings.Contains(string(out), rejectMessages[rejectServerFull]) {
+		t.Errorf("command output = %q, want the server full message", out)
+	}
+}
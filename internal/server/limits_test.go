@@ -0,0 +1,134 @@
+package server
+
+import (
+	"net"
+	"testing"
+)
+
+func TestLimiterAcquire(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  LimitsConfig
+		ips     []string // one acquire each, none released
+		reasons []string // "" for an accepted session
+	}{
+		{
+			"burst then rate limited",
+			LimitsConfig{ConnRatePerMinute: 1, ConnBurst: 2},
+			[]string{"192.0.2.1", "192.0.2.1", "192.0.2.1", "192.0.2.2"},
+			[]string{"", "", rejectRateLimit, ""},
+		},
+		{
+			"zero burst allows one",
+			LimitsConfig{ConnRatePerMinute: 1},
+			[]string{"192.0.2.1", "192.0.2.1"},
+			[]string{"", rejectRateLimit},
+		},
+		{
+			"server full",
+			LimitsConfig{MaxSessions: 2},
+			[]string{"192.0.2.1", "192.0.2.2", "192.0.2.3"},
+			[]string{"", "", rejectServerFull},
+		},
+		{
+			"per ip",
+			LimitsConfig{MaxSessionsPerIP: 1},
+			[]string{"192.0.2.1", "192.0.2.1", "192.0.2.2"},
+			[]string{"", rejectIPFull, ""},
+		},
+		{
+			"rate checked first",
+			LimitsConfig{MaxSessions: 1, ConnRatePerMinute: 1},
+			[]string{"192.0.2.1", "192.0.2.1"},
+			[]string{"", rejectRateLimit},
+		},
+		{
+			"no limits",
+			LimitsConfig{},
+			[]string{"192.0.2.1", "192.0.2.1", "192.0.2.1"},
+			[]string{"", "", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLimiter(tt.config)
+			for i, ip := range tt.ips {
+				release, reason := l.acquire(ip)
+				if reason != tt.reasons[i] {
+					t.Errorf("acquire %d (%s) = %q, want %q", i, ip, reason, tt.reasons[i])
+				}
+				if (release == nil) != (reason != "") {
+					t.Errorf("acquire %d returned release %v with reason %q", i, release != nil, reason)
+				}
+			}
+		})
+	}
+}
+
+// Releasing a session frees its slots, twice is the same as once
+func TestLimiterRelease(t *testing.T) {
+	l := newLimiter(LimitsConfig{MaxSessions: 1, MaxSessionsPerIP: 1})
+	release, _ := l.acquire("192.0.2.1")
+	if _, reason := l.acquire("192.0.2.2"); reason != rejectServerFull {
+		t.Fatalf("second session = %q, want %q", reason, rejectServerFull)
+	}
+	release()
+	release()
+	if l.active != 0 || len(l.perIP) != 0 {
+		t.Errorf("after release active = %d, per IP = %v", l.active, l.perIP)
+	}
+	if _, reason := l.acquire("192.0.2.1"); reason != "" {
+		t.Errorf("session after release = %q, want accepted", reason)
+	}
+}
+
+func TestRemoteIP(t *testing.T) {
+	tests := []struct {
+		addr net.Addr
+		want string
+	}{
+		{&net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 22}, "192.0.2.1"},
+		{&net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 22}, "2001:db8::1"},
+		{&net.UnixAddr{Name: "/run/tuiserver.sock", Net: "unix"}, "/run/tuiserver.sock"},
+	}
+	for _, tt := range tests {
+		if got := remoteIP(tt.addr); got != tt.want {
+			t.Errorf("remoteIP(%v) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
+
+// SSH connections are rate limited and capped on their own, sessions on
+// them only take session slots
+func TestLimiterConnections(t *testing.T) {
+	l := newLimiter(LimitsConfig{MaxSessions: 3, MaxSessionsPerIP: 2, ConnRatePerMinute: 1, ConnBurst: 2})
+
+	first, reason := l.connect("192.0.2.1")
+	if reason != "" {
+		t.Fatalf("first connection = %q", reason)
+	}
+	for i := 0; i < 2; i++ {
+		if _, reason := l.session("192.0.2.1"); reason != "" {
+			t.Fatalf("session %d = %q, sessions must not use rate tokens", i+1, reason)
+		}
+	}
+	if _, reason := l.session("192.0.2.1"); reason != rejectIPFull {
+		t.Errorf("third session = %q, want %q", reason, rejectIPFull)
+	}
+	if _, reason := l.connect("192.0.2.1"); reason != "" {
+		t.Errorf("second connection = %q", reason)
+	}
+	if _, reason := l.connect("192.0.2.1"); reason != rejectRateLimit {
+		t.Errorf("third connection = %q, want %q", reason, rejectRateLimit)
+	}
+	if _, reason := l.connect("192.0.2.2"); reason != "" {
+		t.Errorf("connection from another address = %q", reason)
+	}
+	if _, reason := l.connect("192.0.2.3"); reason != rejectServerFull {
+		t.Errorf("connection over the cap = %q, want %q", reason, rejectServerFull)
+	}
+	first()
+	if _, reason := l.connect("192.0.2.3"); reason != "" {
+		t.Errorf("connection after one closed = %q", reason)
+	}
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// first file descriptor passed by systemd socket activation
+const listenFDsStart = 3
+
+// services that sockets can be passed for, by FileDescriptorName. Sockets
+// passed without one of these names serve SSH.
+var socketServices = []string{"ssh", "telnet", "finger", "gemini", "gopher", "http", "control"}
+
+// boundListener is a listening socket and the service it belongs to
+type boundListener struct {
+	service string
+	ln      net.Listener
+}
+
+// activatedListeners returns the sockets passed by systemd socket
+// activation (sd_listen_fds) or by the process that upgraded to this one,
+// grouped by service name
+func activatedListeners() (map[string][]net.Listener, error) {
+	pid, _ := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	parent, _ := strconv.Atoi(os.Getenv(upgradeParentEnv))
+	if pid != os.Getpid() && (parent == 0 || parent != os.Getppid()) {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+
+	// the variables describe this process only, not its children
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+	os.Unsetenv(upgradeParentEnv)
+
+	listeners := make(map[string][]net.Listener)
+	for i := 0; i < count; i++ {
+		fd := listenFDsStart + i
+		name := ""
+		if i < len(names) {
+			name = names[i]
+		}
+
+		// FileListener duplicates the descriptor, the original is closed
+		f := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			closeListeners(listeners)
+			return nil, fmt.Errorf("socket %d (%s) passed by systemd is not a listening socket: %w", fd, name, err)
+		}
+
+		service := "ssh"
+		for _, known := range socketServices {
+			if name == known {
+				service = name
+			}
+		}
+		listeners[service] = append(listeners[service], ln)
+	}
+	return listeners, nil
+}
+
+// listen returns the listeners of a service: the sockets passed for it by
+// systemd or the previous process if there are any, otherwise new ones
+// bound to each of addrs. Empty addresses are skipped, so a service can end
+// up with none.
+func (srv *Server) listen(service string, addrs ...string) ([]net.Listener, error) {
+	listeners, err := srv.listenAll(service, addrs)
+	if err != nil {
+		return nil, err
+	}
+	for _, ln := range listeners {
+		srv.bound = append(srv.bound, boundListener{service, ln})
+	}
+	return listeners, nil
+}
+
+func (srv *Server) listenAll(service string, addrs []string) ([]net.Listener, error) {
+	if activated := srv.activated[service]; len(activated) > 0 {
+		for _, ln := range activated {
+			slog.Info("using inherited socket", "service", service, "addr", ln.Addr().String())
+		}
+		return activated, nil
+	}
+
+	var listeners []net.Listener
+	for _, addr := range addrs {
+		if addr == "" {
+			continue
+		}
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("failed to listen for %s on %s: %w", service, addr, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
+
+func closeListeners(listeners map[string][]net.Listener) {
+	for _, group := range listeners {
+		for _, ln := range group {
+			ln.Close()
+		}
+	}
+}
+
+// listenHTTP returns an inherited HTTP socket bound to addr, or binds a new
+// one. HTTP sockets are matched by address since several may be passed.
+func (srv *Server) listenHTTP(addr string) (net.Listener, error) {
+	want, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid HTTP address %s: %w", addr, err)
+	}
+
+	ln, err := srv.takeActivated("http", want)
+	if ln == nil && err == nil {
+		ln, err = net.Listen("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for HTTP on %s: %w", addr, err)
+	}
+	srv.bound = append(srv.bound, boundListener{"http", ln})
+	return ln, nil
+}
+
+// takeActivated removes and returns the inherited socket of service bound
+// to want, nil when there is none
+func (srv *Server) takeActivated(service string, want *net.TCPAddr) (net.Listener, error) {
+	group := srv.activated[service]
+	for i, ln := range group {
+		got, ok := ln.Addr().(*net.TCPAddr)
+		if !ok || got.Port != want.Port {
+			continue
+		}
+		if got.IP.Equal(want.IP) || (isUnspecified(got.IP) && isUnspecified(want.IP)) {
+			srv.activated[service] = append(group[:i:i], group[i+1:]...)
+			slog.Info("using inherited socket", "service", service, "addr", got.String())
+			return ln, nil
+		}
+	}
+	return nil, nil
+}
+
+func isUnspecified(ip net.IP) bool {
+	return ip == nil || ip.IsUnspecified()
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// activatedEnv marks the test binary run as a process that was passed sockets
+const activatedEnv = "TUISERVER_TEST_ACTIVATED"
+
+// TestActivatedListenersChild is the child process of TestActivatedListeners:
+// it reports the listeners it found by service, and which environment
+// variables it left
+func TestActivatedListenersChild(t *testing.T) {
+	if os.Getenv(activatedEnv) == "" {
+		t.Skip("only run as a child")
+	}
+	listeners, err := activatedListeners()
+	result := map[string][]string{}
+	if err != nil {
+		// the cause differs between systems
+		msg, _, _ := strings.Cut(err.Error(), ":")
+		result["error"] = []string{msg}
+	}
+	for service, group := range listeners {
+		for _, ln := range group {
+			result[service] = append(result[service], ln.Addr().String())
+		}
+	}
+	for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES", upgradeParentEnv} {
+		if _, ok := os.LookupEnv(name); ok {
+			result["env"] = append(result["env"], name)
+		}
+	}
+	json.NewEncoder(os.Stdout).Encode(result)
+	os.Exit(0)
+}
+
+func TestActivatedListeners(t *testing.T) {
+	// three sockets, passed as fds 3, 4 and 5
+	var files []*os.File
+	var addrs []string
+	for i := 0; i < 3; i++ {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+		f, err := ln.(*net.TCPListener).File()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		files = append(files, f)
+		addrs = append(addrs, ln.Addr().String())
+	}
+	parent := strconv.Itoa(os.Getpid())
+	plain, err := os.Create(t.TempDir() + "/not-a-socket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+
+	tests := []struct {
+		name  string
+		env   []string
+		files []*os.File // the sockets when nil
+		want  map[string][]string
+	}{
+		{"named", []string{"LISTEN_FDS=3", "LISTEN_FDNAMES=http:finger:control", upgradeParentEnv + "=" + parent},
+			nil, map[string][]string{"http": {addrs[0]}, "finger": {addrs[1]}, "control": {addrs[2]}}},
+		{"unknown names serve ssh", []string{"LISTEN_FDS=3", "LISTEN_FDNAMES=web:ssh:", upgradeParentEnv + "=" + parent},
+			nil, map[string][]string{"ssh": {addrs[0], addrs[1], addrs[2]}}},
+		{"fewer names than sockets", []string{"LISTEN_FDS=3", "LISTEN_FDNAMES=telnet", upgradeParentEnv + "=" + parent},
+			nil, map[string][]string{"telnet": {addrs[0]}, "ssh": {addrs[1], addrs[2]}}},
+		{"fewer sockets than passed", []string{"LISTEN_FDS=1", "LISTEN_FDNAMES=gopher:gemini", upgradeParentEnv + "=" + parent},
+			nil, map[string][]string{"gopher": {addrs[0]}}},
+		{"meant for another process", []string{"LISTEN_PID=1", "LISTEN_FDS=3", "LISTEN_FDNAMES=http:finger:control"},
+			nil, map[string][]string{"env": {"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"}}},
+		{"not a number", []string{"LISTEN_FDS=three", upgradeParentEnv + "=" + parent},
+			nil, map[string][]string{"env": {"LISTEN_FDS", upgradeParentEnv}}},
+		{"not a socket", []string{"LISTEN_FDS=1", "LISTEN_FDNAMES=http", upgradeParentEnv + "=" + parent},
+			[]*os.File{plain}, map[string][]string{"error": {"socket 3 (http) passed by systemd is not a listening socket"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(os.Args[0], "-test.run=^TestActivatedListenersChild$")
+			cmd.Env = append(os.Environ(), activatedEnv+"=1")
+			cmd.Env = append(cmd.Env, tt.env...)
+			cmd.ExtraFiles = files
+			if tt.files != nil {
+				cmd.ExtraFiles = tt.files
+			}
+			out, err := cmd.Output()
+			if err != nil {
+				t.Fatalf("child failed: %v\n%s", err, out)
+			}
+			got := map[string][]string{}
+			if err := json.Unmarshal(out, &got); err != nil {
+				t.Fatalf("child output %q: %v", out, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listeners = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTakeActivated(t *testing.T) {
+	listen := func(addr string) net.Listener {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { ln.Close() })
+		return ln
+	}
+	loopback := listen("127.0.0.1:0")
+	wildcard := listen(":0")
+	port := func(ln net.Listener) int { return ln.Addr().(*net.TCPAddr).Port }
+
+	srv := newTestServer(t, testConfig())
+	srv.activated = map[string][]net.Listener{"http": {loopback, wildcard}}
+
+	tests := []struct {
+		name string
+		addr string
+		want net.Listener
+	}{
+		{"other port", fmt.Sprintf("127.0.0.1:%d", port(wildcard)+1), nil},
+		{"other address", fmt.Sprintf("127.0.0.2:%d", port(loopback)), nil},
+		{"same address", fmt.Sprintf("127.0.0.1:%d", port(loopback)), loopback},
+		{"taken only once", fmt.Sprintf("127.0.0.1:%d", port(loopback)), nil},
+		{"any address", fmt.Sprintf(":%d", port(wildcard)), wildcard},
+	}
+	for _, tt := range tests {
+		want, err := net.ResolveTCPAddr("tcp", tt.addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := srv.takeActivated("http", want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: takeActivated(%s) = %v, want %v", tt.name, tt.addr, got, tt.want)
+		}
+	}
+	if left := srv.activated["http"]; len(left) != 0 {
+		t.Errorf("%d sockets left after both were taken", len(left))
+	}
+}
@@ -0,0 +1,265 @@
+package server
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logLevel is shared by every handler so the level can change at runtime
+var logLevel = new(slog.LevelVar)
+
+// setupLogger installs the default slog logger writing to stdout and, when
+// configured, to a rotating log file. The standard log package is routed
+// through the same handler. A log file that cannot be opened is reported
+// and skipped; only an invalid level or format is an error.
+func setupLogger(config LogConfig) (*rotatingFile, error) {
+	if err := logLevel.UnmarshalText([]byte(config.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", config.Level, err)
+	}
+
+	var writers []io.Writer
+	if config.Stdout {
+		writers = append(writers, os.Stdout)
+	}
+
+	var file *rotatingFile
+	var fileErr error
+	if config.File != "" {
+		file, fileErr = openRotatingFile(config)
+		if fileErr == nil {
+			writers = append(writers, file)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: logLevel}
+	out := io.MultiWriter(writers...)
+
+	var handler slog.Handler
+	switch config.Format {
+	case "json":
+		handler = slog.NewJSONHandler(out, opts)
+	case "text", "":
+		handler = slog.NewTextHandler(out, opts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q, use text or json", config.Format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+
+	if fileErr != nil {
+		slog.Warn("could not set up file logging, continuing with stdout only", "file", config.File, "error", fileErr)
+	}
+	return file, nil
+}
+
+// rotatingFile is an append-only log file that rotates by size and age
+// and can be reopened after an external tool moved it away
+type rotatingFile struct {
+	config LogConfig
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+func openRotatingFile(config LogConfig) (*rotatingFile, error) {
+	r := &rotatingFile{config: config}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.config.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	r.file = file
+	r.size = info.Size()
+	r.opened = time.Now()
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.shouldRotate(len(p)) {
+		if err := r.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) shouldRotate(next int) bool {
+	if r.size == 0 {
+		return false
+	}
+	if max := int64(r.config.MaxSizeMB) << 20; max > 0 && r.size+int64(next) > max {
+		return true
+	}
+	if every := time.Duration(r.config.RotateEvery); every > 0 && time.Since(r.opened) >= every {
+		return true
+	}
+	return false
+}
+
+// backupLayout is the timestamp in the names of rotated log files
+const backupLayout = "20060102-150405"
+
+// backupSuffix matches what rotate appends to the log file name: the
+// timestamp and a sequence number telling apart backups made within the
+// same second
+var backupSuffix = regexp.MustCompile(`^\.(\d{8}-\d{6})-(\d+)$`)
+
+// rotate moves the current file to a numbered backup, opens a fresh one
+// and prunes old backups
+func (r *rotatingFile) rotate() error {
+	r.file.Close()
+	r.file = nil
+
+	// keep logging to the same file if it cannot be moved away
+	backupErr := backupFile(r.config.File, time.Now())
+	if err := r.open(); err != nil {
+		return err
+	}
+	if backupErr != nil {
+		return backupErr
+	}
+
+	r.prune()
+	return nil
+}
+
+// backupFile moves path to the first free backup name for now. The file is
+// linked before it is removed so an existing backup, for example one made by
+// the other process during an upgrade, is never overwritten.
+func backupFile(path string, now time.Time) error {
+	stamp := now.Format(backupLayout)
+	for seq := 1; ; seq++ {
+		backup := fmt.Sprintf("%s.%s-%03d", path, stamp, seq)
+		err := os.Link(path, backup)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		return os.Remove(path)
+	}
+}
+
+// logBackup is a rotated log file found next to the log
+type logBackup struct {
+	path  string
+	stamp string
+	seq   int
+}
+
+// listBackups returns the backups of the log file at path, newest first.
+// Other files sharing the name as a prefix are left out.
+func listBackups(path string) ([]logBackup, error) {
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+
+	base := filepath.Base(path)
+	var backups []logBackup
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), base)
+		if !ok || !entry.Type().IsRegular() {
+			continue
+		}
+		m := backupSuffix.FindStringSubmatch(suffix)
+		if m == nil {
+			continue
+		}
+		seq, _ := strconv.Atoi(m[2])
+		backups = append(backups, logBackup{
+			path:  filepath.Join(filepath.Dir(path), entry.Name()),
+			stamp: m[1],
+			seq:   seq,
+		})
+	}
+	slices.SortFunc(backups, func(a, b logBackup) int {
+		if c := cmp.Compare(b.stamp, a.stamp); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.seq, a.seq)
+	})
+	return backups, nil
+}
+
+// prune removes backups beyond MaxBackups or older than MaxAge
+func (r *rotatingFile) prune() {
+	backups, err := listBackups(r.config.File)
+	if err != nil {
+		return
+	}
+
+	maxAge := time.Duration(r.config.MaxAge)
+	for i, backup := range backups {
+		expired := false
+		if r.config.MaxBackups > 0 && i >= r.config.MaxBackups {
+			expired = true
+		}
+		if info, err := os.Stat(backup.path); err == nil && maxAge > 0 && time.Since(info.ModTime()) > maxAge {
+			expired = true
+		}
+		if expired {
+			os.Remove(backup.path)
+		}
+	}
+}
+
+// Reopen closes and reopens the log file, for use after logrotate moved it
+func (r *rotatingFile) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+	return r.open()
+}
+
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Backups made within the same second get their own names
+func TestBackupFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, content := range []string{"first", "second", "third"} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := backupFile(path, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name, want string
+	}{
+		{"app.log.20240501-120000-001", "first"},
+		{"app.log.20240501-120000-002", "second"},
+		{"app.log.20240501-120000-003", "third"},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile(filepath.Join(dir, tt.name))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if string(data) != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, data, tt.want)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("log file still there after backup: %v", err)
+	}
+}
+
+func TestListBackups(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		"app.log",
+		"app.log.20240501-120000-002",
+		"app.log.20240501-120000-010",
+		"app.log.20240501-120000-001",
+		"app.log.20240430-080000", // no sequence, not ours
+		"app.log.20240502-090000-001",
+		"app.log.gz",                  // another tool's file
+		"app.log.20240501-120000.tmp", // not a backup
+		"app.logger.20240501-120000-001",
+		"other.log.20240501-120000-001",
+	}
+	for _, name := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups, err := listBackups(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, b := range backups {
+		got = append(got, filepath.Base(b.path))
+	}
+	want := []string{
+		"app.log.20240502-090000-001",
+		"app.log.20240501-120000-010",
+		"app.log.20240501-120000-002",
+		"app.log.20240501-120000-001",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("listBackups = %v, want %v", got, want)
+	}
+}
+
+func TestPruneKeepsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	names := []string{
+		"app.log.20240501-120000-001",
+		"app.log.20240501-120000-002",
+		"app.log.20240501-120000-003",
+		"app.log.gz",
+		"app.log.lock",
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := &rotatingFile{config: LogConfig{File: path, MaxBackups: 2}}
+	r.prune()
+
+	tests := []struct {
+		name string
+		kept bool
+	}{
+		{"app.log.20240501-120000-001", false},
+		{"app.log.20240501-120000-002", true},
+		{"app.log.20240501-120000-003", true},
+		{"app.log.gz", true},
+		{"app.log.lock", true},
+	}
+	for _, tt := range tests {
+		_, err := os.Stat(filepath.Join(dir, tt.name))
+		if kept := err == nil; kept != tt.kept {
+			t.Errorf("%s kept = %v, want %v", tt.name, kept, tt.kept)
+		}
+	}
+}
+
+// A rotation writes on to a fresh file and keeps the old lines in a backup
+func TestRotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	r, err := openRotatingFile(LogConfig{File: path, MaxSizeMB: 1, MaxBackups: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	line := make([]byte, 600<<10)
+	for i := range line {
+		line[i] = 'x'
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := r.Write(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups, err := listBackups(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 {
+		t.Errorf("%d backups after three writes over the size limit, want 2", len(backups))
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != int64(len(line)) {
+		t.Errorf("log file after rotation: %v, %v", info, err)
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/tui"
+)
+
+// Prometheus metrics, served on /metrics when metrics.listen_addr is set
+var (
+	activeSessions = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "tuiserver_active_sessions",
+		Help: "Sessions currently running the TUI.",
+	})
+
+	sessionsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "tuiserver_sessions_total",
+		Help: "Sessions that started the TUI.",
+	})
+
+	sessionDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "tuiserver_session_duration_seconds",
+		Help:    "How long TUI sessions lasted.",
+		Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600},
+	})
+
+	rejectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tuiserver_rejections_total",
+		Help: "Connections and sessions turned away, by reason.",
+	}, []string{"reason"})
+
+	terminalTypes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tuiserver_terminal_types_total",
+		Help: "Sessions by TERM of the client.",
+	}, []string{"term"})
+
+	terminalWidth = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "tuiserver_terminal_width_columns",
+		Help:    "Terminal width at session start.",
+		Buckets: []float64{40, 60, 80, 100, 120, 160, 200, 300},
+	})
+
+	terminalHeight = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "tuiserver_terminal_height_rows",
+		Help:    "Terminal height at session start.",
+		Buckets: []float64{10, 20, 24, 30, 40, 50, 60, 80},
+	})
+
+	sectionViews = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tuiserver_section_views_total",
+		Help: "Times each section was shown.",
+	}, []string{"section"})
+
+	linkActivations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tuiserver_link_activations_total",
+		Help: "Links opened from link mode, by host.",
+	}, []string{"host"})
+
+	resizeEvents = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "tuiserver_resize_events_total",
+		Help: "Terminal window resize events.",
+	})
+
+	protocolRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tuiserver_protocol_requests_total",
+		Help: "Requests answered by the finger, gopher and gemini listeners.",
+	}, []string{"protocol"})
+
+	tuiErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "tuiserver_tui_errors_total",
+		Help: "TUI programs that exited with an error.",
+	})
+)
+
+// known terminal families, labels use the family name only and anything
+// else is counted as "other" so clients cannot create unbounded label values
+var knownTerms = []string{
+	"xterm", "screen", "tmux", "rxvt", "vt100", "vt220", "linux",
+	"alacritty", "foot", "kitty", "wezterm", "st", "putty", "dumb",
+}
+
+func termLabel(term string) string {
+	term = strings.ToLower(term)
+	for _, known := range knownTerms {
+		if term == known || strings.HasPrefix(term, known+"-") {
+			return known
+		}
+	}
+	return "other"
+}
+
+// observeEvent counts TUI navigation events. Sections and links are only
+// named when every visitor sees them as they are written, anything hidden,
+// private, for some audiences only or rendered per session is "other".
+func (srv *Server) observeEvent(e tui.Event) {
+	switch e.Kind {
+	case tui.EventSectionEnter:
+		sectionViews.WithLabelValues(srv.sectionLabel(e.Section)).Inc()
+	case tui.EventLinkActivate:
+		linkActivations.WithLabelValues(srv.linkLabel(e.Link)).Inc()
+	}
+}
+
+// labelledSections are the sections whose titles and links may be labels
+func (srv *Server) labelledSections() []models.Section {
+	var sections []models.Section
+	for _, sec := range srv.content().Visible().Public().ForAudience("").Sections {
+		if len(sec.Audiences) == 0 && !strings.Contains(sec.Title, "{{") {
+			sections = append(sections, sec)
+		}
+	}
+	return sections
+}
+
+func (srv *Server) sectionLabel(title string) string {
+	for _, sec := range srv.labelledSections() {
+		if sec.Title == title {
+			return title
+		}
+	}
+	return "other"
+}
+
+// linkLabel returns the host of a link written in a labelled section
+func (srv *Server) linkLabel(link string) string {
+	for _, sec := range srv.labelledSections() {
+		for _, line := range sec.Content {
+			if strings.Contains(line, "{{") {
+				continue
+			}
+			for _, l := range tui.FindLinks([]string{line}) {
+				if l != link {
+					continue
+				}
+				if u, err := url.Parse(link); err == nil && u.Hostname() != "" {
+					return strings.ToLower(u.Hostname())
+				}
+			}
+		}
+	}
+	return "other"
+}
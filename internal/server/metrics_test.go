@@ -0,0 +1,78 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+func TestMetricLabels(t *testing.T) {
+	srv := newTestServer(t, testConfig())
+	srv.portfolio = models.Portfolio{
+		Title: "test",
+		Sections: []models.Section{
+			{Title: "About", Content: []string{
+				"blog https://Example.com/posts",
+				"[+dev] code https://git.example.org/me",
+				"hi {{.Visitor.User}} https://example.net/{{.Visitor.User}}",
+			}},
+			{Title: "Draft", Content: []string{"https://draft.example.com"}, Hidden: true},
+			{Title: "Secret", Content: []string{"https://secret.example.com"}, Private: true},
+			{Title: "Hiring", Content: []string{"https://jobs.example.com"}, Audiences: []string{"dev"}},
+			{Title: "{{.Greeting}}", Content: []string{"https://greeting.example.com"}},
+		},
+		Audiences: []models.Audience{{Name: "dev"}},
+	}
+
+	sections := []struct {
+		title, want string
+	}{
+		{"About", "About"},
+		{"Draft", "other"},
+		{"Secret", "other"},
+		{"Hiring", "other"},
+		{"{{.Greeting}}", "other"},
+		{"made up by a client", "other"},
+	}
+	for _, tt := range sections {
+		if got := srv.sectionLabel(tt.title); got != tt.want {
+			t.Errorf("sectionLabel(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+
+	links := []struct {
+		link, want string
+	}{
+		{"https://Example.com/posts", "example.com"},
+		{"https://example.com/posts?utm=1", "other"},
+		{"https://git.example.org/me", "other"},
+		{"https://example.net/alice", "other"},
+		{"https://draft.example.com", "other"},
+		{"https://secret.example.com", "other"},
+		{"https://jobs.example.com", "other"},
+		{"https://greeting.example.com", "other"},
+	}
+	for _, tt := range links {
+		if got := srv.linkLabel(tt.link); got != tt.want {
+			t.Errorf("linkLabel(%q) = %q, want %q", tt.link, got, tt.want)
+		}
+	}
+}
+
+func TestTermLabel(t *testing.T) {
+	tests := map[string]string{
+		"xterm-256color": "xterm",
+		"XTERM":          "xterm",
+		"screen.xterm":   "other",
+		"tmux-256color":  "tmux",
+		"st":             "st",
+		"stterm":         "other",
+		"\x1b[31m":       "other",
+		"":               "other",
+	}
+	for term, want := range tests {
+		if got := termLabel(term); got != want {
+			t.Errorf("termLabel(%q) = %q, want %q", term, got, want)
+		}
+	}
+}
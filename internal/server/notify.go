@@ -0,0 +1,34 @@
+package server
+
+import (
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+)
+
+// notifyServiceManager sends state to systemd, as sd_notify(3) does, when
+// the service was started with Type=notify. Outside systemd it does
+// nothing.
+func notifyServiceManager(state string) {
+	if err := sdNotify(os.Getenv("NOTIFY_SOCKET"), state); err != nil {
+		slog.Warn("failed to notify the service manager", "state", state, "error", err)
+	}
+}
+
+func sdNotify(socket, state string) error {
+	if socket == "" {
+		return nil
+	}
+	// a leading @ names a socket in the abstract namespace
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte(state))
+	return err
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSdNotify(t *testing.T) {
+	if err := sdNotify("", "READY=1"); err != nil {
+		t.Errorf("without a socket: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skip("unixgram sockets unavailable:", err)
+	}
+	defer conn.Close()
+
+	for _, state := range []string{"READY=1", "MAINPID=4242", "STOPPING=1"} {
+		if err := sdNotify(path, state); err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 64)
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := string(buf[:n]); got != state {
+			t.Errorf("received %q, want %q", got, state)
+		}
+	}
+
+	if err := sdNotify(filepath.Join(t.TempDir(), "missing"), "READY=1"); err == nil {
+		t.Error("notifying a missing socket succeeded")
+	}
+}
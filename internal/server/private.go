@@ -0,0 +1,42 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/invites"
+)
+
+// useInvite redeems the invite code an SSH visitor connected with as their
+// user name. It reports whether private sections are unlocked and what to
+// tell the visitor, nothing when the user name is no invite code.
+func (srv *Server) useInvite(t terminal) (bool, string) {
+	path := srv.config().Private.InviteFile
+	if t.transport != "ssh" || path == "" || t.invite == "" {
+		return false, ""
+	}
+
+	inv, err := invites.NewStore(path).Use(t.invite, t.fingerprint)
+	switch {
+	case err == nil:
+		remaining := -1
+		if inv.MaxUses > 0 {
+			remaining = inv.MaxUses - len(inv.Uses)
+		}
+		t.logger.Info("invite used", "invite", invites.Redact(inv.Code), "note", inv.Note, "uses", len(inv.Uses), "remaining", remaining)
+		return true, "Invite accepted, private sections unlocked"
+	case errors.Is(err, invites.ErrUnknown):
+		// guessing codes counts against the address like other abuse
+		t.logger.Warn("invite refused", "invite", t.user, "reason", err)
+		srv.access.strike(t.ip, "unknown invite")
+		return false, "This invite code is not valid"
+	case errors.Is(err, invites.ErrExpired):
+		t.logger.Info("invite refused", "invite", invites.Redact(inv.Code), "note", inv.Note, "reason", err)
+		return false, "This invite has expired"
+	case errors.Is(err, invites.ErrUsedUp):
+		t.logger.Info("invite refused", "invite", invites.Redact(inv.Code), "note", inv.Note, "reason", err)
+		return false, "This invite has already been used"
+	default:
+		t.logger.Error("failed to use invite", "invite", t.user, "error", err)
+		return false, ""
+	}
+}
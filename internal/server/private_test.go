@@ -0,0 +1,113 @@
+package server
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	ssh "github.com/charmbracelet/ssh"
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/analytics"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/invites"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+// Private sections follow the key a client signed with, offering the
+// public half of an allowed key is not enough
+func TestUnsignedPrivateKeyIsNotTrusted(t *testing.T) {
+	dir := t.TempDir()
+	friend := writeKey(t, dir, "friend")
+	srv := newTestServer(t, testConfig())
+	srv.setPrivateKeys([]ssh.PublicKey{friend.PublicKey()})
+	results := make(chan keyResult, 1)
+	addr := startSSH(t, srv, recordKeys(srv, results))
+
+	tests := []struct {
+		name     string
+		identity string
+		private  bool
+	}{
+		{"private key", filepath.Join(dir, "friend"), true},
+		{"public key only", filepath.Join(dir, "friend.pub"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if out, err := openSSH(t, addr, "visitor", tt.identity, "", "true"); err != nil {
+				t.Fatalf("ssh failed: %v\n%s", err, out)
+			}
+			got := waitResult(t, results)
+			if got.private != tt.private {
+				t.Errorf("private = %v, want %v", got.private, tt.private)
+			}
+			if got.owner {
+				t.Error("an allowed key counted as an owner key")
+			}
+		})
+	}
+}
+
+// An invite code given as user name unlocks the private sections but is
+// kept out of the logs and analytics, where it could be used by others
+func TestInviteCodeNotRecorded(t *testing.T) {
+	dir := t.TempDir()
+	config := testConfig()
+	config.Features.WelcomeScreen = false
+	config.Private.InviteFile = filepath.Join(dir, "invites.db")
+	srv := newTestServer(t, config)
+	srv.portfolio = models.DefaultPortfolio()
+	srv.analytics = analytics.NewStore(filepath.Join(dir, "analytics.db"))
+	addr := startSSH(t, srv, nil)
+	logs := captureLogs(t)
+
+	inv, err := invites.NewStore(config.Private.InviteFile).Create("friend", time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := gossh.Dial("tcp", addr, &gossh.ClientConfig{
+		User:            inv.Code,
+		Auth:            []gossh.AuthMethod{gossh.PublicKeys(newSigner(t))},
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	if err := session.RequestPty("xterm", 24, 80, gossh.TerminalModes{}); err != nil {
+		t.Fatal(err)
+	}
+	session.Stdin = strings.NewReader("q")
+	if err := session.Shell(); err != nil {
+		t.Fatal(err)
+	}
+	session.Wait()
+
+	redacted := invites.Redact(inv.Code)
+	if !strings.Contains(logs.String(), "invite used") {
+		t.Errorf("invite not used\n%s", logs)
+	}
+	if strings.Contains(logs.String(), inv.Code) {
+		t.Errorf("invite code logged\n%s", logs)
+	}
+	if !strings.Contains(logs.String(), "user="+redacted) {
+		t.Errorf("logs do not carry the redacted user %q\n%s", redacted, logs)
+	}
+
+	sessions, err := srv.analytics.Sessions(time.Now().Add(-time.Hour), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("%d sessions in analytics, want 1", len(sessions))
+	}
+	if sessions[0].User != redacted {
+		t.Errorf("analytics user = %q, want %q", sessions[0].User, redacted)
+	}
+}
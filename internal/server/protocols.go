@@ -0,0 +1,81 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"time"
+)
+
+// the small text protocols answer one request per connection
+const (
+	requestTimeout  = 10 * time.Second
+	responseTimeout = 30 * time.Second
+)
+
+// serveProtocol accepts connections for one of the small text protocols,
+// applying the access rules and the protocol's own limiter before handing
+// each connection to handle. It returns when ln is closed.
+func (srv *Server) serveProtocol(ln net.Listener, protocol string, limits *limiter, handle func(conn net.Conn, logger *slog.Logger)) {
+	slog.Info(protocol+" listener started", "addr", ln.Addr().String())
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			slog.Warn(protocol+" accept failed", "error", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		go func() {
+			defer conn.Close()
+
+			ip := remoteIP(conn.RemoteAddr())
+			logger := slog.With("ip", ip, "transport", protocol)
+
+			if ok, reason := srv.access.check(ip); !ok {
+				logger.Info("connection refused", "reason", reason)
+				rejectionsTotal.WithLabelValues(reason).Inc()
+				return
+			}
+
+			release, reason := limits.acquire(ip)
+			if release == nil {
+				logger.Warn("connection rejected", "reason", reason)
+				rejectionsTotal.WithLabelValues(reason).Inc()
+				return
+			}
+			defer release()
+
+			conn.SetDeadline(time.Now().Add(requestTimeout + responseTimeout))
+			protocolRequests.WithLabelValues(protocol).Inc()
+			handle(conn, logger)
+		}()
+	}
+}
+
+// readRequestLine reads a CRLF terminated request of at most limit bytes
+func readRequestLine(conn net.Conn, limit int) (string, error) {
+	conn.SetReadDeadline(time.Now().Add(requestTimeout))
+
+	r := bufio.NewReaderSize(conn, limit+2)
+	line, err := r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return "", fmt.Errorf("request longer than %d bytes", limit)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(line), "\r\n"), nil
+}
+
+// crlf converts line endings for protocols that require CRLF
+func crlf(text string) string {
+	return strings.ReplaceAll(text, "\n", "\r\n")
+}
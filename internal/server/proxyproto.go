@@ -0,0 +1,199 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// proxyV2Signature starts every PROXY protocol v2 header
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// longest v1 header line allowed by the specification, CRLF included
+const proxyV1MaxLength = 107
+
+// proxyListener reads the PROXY protocol header of connections from
+// trusted load balancers so the rest of the server sees the client address.
+// Connections from other sources are passed through untouched, a header
+// they send is never believed.
+type proxyListener struct {
+	net.Listener
+	trusted []*net.IPNet
+	timeout time.Duration
+}
+
+func newProxyListener(ln net.Listener, config ProxyConfig) (net.Listener, error) {
+	trusted, err := parseNetworks(config.Trusted)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted proxy list: %w", err)
+	}
+	return &proxyListener{Listener: ln, trusted: trusted, timeout: time.Duration(config.HeaderTimeout)}, nil
+}
+
+func (l *proxyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	tcp, ok := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok || !containsIP(l.trusted, tcp.IP) {
+		return conn, nil
+	}
+	// the header is read on first use, in the connection's own goroutine,
+	// so a slow load balancer connection does not hold up Accept
+	return &proxyConn{Conn: conn, reader: bufio.NewReader(conn), timeout: l.timeout}, nil
+}
+
+// proxyConn is a connection from a trusted load balancer
+type proxyConn struct {
+	net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+
+	once   sync.Once
+	remote net.Addr // client address from the header
+	err    error    // why the header could not be read
+}
+
+// readHeader parses the header once. A connection without a valid header
+// fails all reads, so a misconfigured load balancer is noticed at once.
+func (c *proxyConn) readHeader() {
+	c.once.Do(func() {
+		c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+		c.remote, c.err = parseProxyHeader(c.reader)
+		c.Conn.SetReadDeadline(time.Time{})
+
+		if c.err != nil {
+			slog.Warn("invalid PROXY protocol header", "proxy", remoteIP(c.Conn.RemoteAddr()), "error", c.err)
+			c.Conn.Close()
+			return
+		}
+		if c.remote == nil {
+			// a health check from the load balancer itself
+			c.remote = c.Conn.RemoteAddr()
+		}
+	})
+}
+
+// headerFailed reports whether the load balancer did not send a usable header
+func (c *proxyConn) headerFailed() bool {
+	c.readHeader()
+	return c.err != nil
+}
+
+func (c *proxyConn) Read(p []byte) (int, error) {
+	c.readHeader()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(p)
+}
+
+// RemoteAddr is the client address announced by the load balancer
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.readHeader()
+	if c.err != nil {
+		return c.Conn.RemoteAddr()
+	}
+	return c.remote
+}
+
+// parseProxyHeader reads a v1 or v2 header. It returns a nil address for
+// headers that carry none, v1 UNKNOWN and v2 LOCAL.
+func parseProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	start, err := r.Peek(len(proxyV2Signature))
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	if bytes.Equal(start, proxyV2Signature) {
+		return parseProxyV2(r)
+	}
+	if bytes.HasPrefix(start, []byte("PROXY ")) {
+		return parseProxyV1(r)
+	}
+	return nil, errors.New("no PROXY protocol header")
+}
+
+// parseProxyV1 reads "PROXY TCP4 <src> <dst> <srcport> <dstport>\r\n"
+func parseProxyV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for !bytes.HasSuffix(line, []byte("\r\n")) {
+		if len(line) >= proxyV1MaxLength {
+			return nil, errors.New("v1 header too long")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("reading v1 header: %w", err)
+		}
+		line = append(line, b)
+	}
+
+	fields := strings.Fields(string(line))
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("malformed v1 header %q", strings.TrimSpace(string(line)))
+	}
+
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || err != nil {
+		return nil, fmt.Errorf("malformed v1 source address %s:%s", fields[2], fields[4])
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// parseProxyV2 reads the binary header, skipping any TLVs after the addresses
+func parseProxyV2(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("reading v2 header: %w", err)
+	}
+
+	version, command := header[12]>>4, header[12]&0x0f
+	family := header[13]
+	length := int(binary.BigEndian.Uint16(header[14:16]))
+	if version != 2 {
+		return nil, fmt.Errorf("unsupported v2 header version %d", version)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("reading v2 addresses: %w", err)
+	}
+
+	switch command {
+	case 0x0: // LOCAL
+		return nil, nil
+	case 0x1: // PROXY
+	default:
+		return nil, fmt.Errorf("unsupported v2 command %d", command)
+	}
+
+	switch family {
+	case 0x11: // TCP over IPv4
+		if length < 12 {
+			return nil, errors.New("short v2 IPv4 addresses")
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:4]), Port: int(binary.BigEndian.Uint16(payload[8:10]))}, nil
+	case 0x21: // TCP over IPv6
+		if length < 36 {
+			return nil, errors.New("short v2 IPv6 addresses")
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:16]), Port: int(binary.BigEndian.Uint16(payload[32:34]))}, nil
+	default:
+		// UDP and unix sockets say nothing useful about a TCP client
+		return nil, nil
+	}
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// proxyV2 builds a v2 header with the command, family and payload given
+func proxyV2(command, family byte, payload []byte) string {
+	var b bytes.Buffer
+	b.Write(proxyV2Signature)
+	b.WriteByte(0x20 | command)
+	b.WriteByte(family)
+	binary.Write(&b, binary.BigEndian, uint16(len(payload)))
+	b.Write(payload)
+	return b.String()
+}
+
+// v2 address payload: source and destination address, then the ports
+func proxyV2Addresses(src, dst net.IP, srcPort, dstPort uint16) []byte {
+	var b bytes.Buffer
+	b.Write(src)
+	b.Write(dst)
+	binary.Write(&b, binary.BigEndian, srcPort)
+	binary.Write(&b, binary.BigEndian, dstPort)
+	return b.Bytes()
+}
+
+func TestParseProxyHeader(t *testing.T) {
+	v4 := proxyV2Addresses(net.ParseIP("203.0.113.7").To4(), net.ParseIP("10.0.0.1").To4(), 51000, 22)
+	v6 := proxyV2Addresses(net.ParseIP("2001:db8::7"), net.ParseIP("2001:db8::1"), 51000, 22)
+	tlv := append(append([]byte(nil), v4...), 0x04, 0x00, 0x01, 'x')
+
+	tests := []struct {
+		name   string
+		header string
+		want   string // client address, "" for none
+		err    bool
+	}{
+		{"v1 tcp4", "PROXY TCP4 203.0.113.7 10.0.0.1 51000 22\r\n", "203.0.113.7:51000", false},
+		{"v1 tcp6", "PROXY TCP6 2001:db8::7 2001:db8::1 51000 22\r\n", "[2001:db8::7]:51000", false},
+		{"v1 unknown", "PROXY UNKNOWN\r\n", "", false},
+		{"v1 unknown with addresses", "PROXY UNKNOWN ffff::1 ffff::2 1 2\r\n", "", false},
+		{"v1 missing field", "PROXY TCP4 203.0.113.7 10.0.0.1 51000\r\n", "", true},
+		{"v1 bad protocol", "PROXY UDP4 203.0.113.7 10.0.0.1 51000 22\r\n", "", true},
+		{"v1 bad address", "PROXY TCP4 example.com 10.0.0.1 51000 22\r\n", "", true},
+		{"v1 bad port", "PROXY TCP4 203.0.113.7 10.0.0.1 70000 22\r\n", "", true},
+		{"v1 too long", "PROXY TCP4 " + strings.Repeat("1", 120) + "\r\n", "", true},
+		{"v1 without CRLF", "PROXY TCP4 203.0.113.7 10.0.0.1 51000 22", "", true},
+		{"v2 tcp4", proxyV2(0x1, 0x11, v4), "203.0.113.7:51000", false},
+		{"v2 tcp6", proxyV2(0x1, 0x21, v6), "[2001:db8::7]:51000", false},
+		{"v2 with TLVs", proxyV2(0x1, 0x11, tlv), "203.0.113.7:51000", false},
+		{"v2 local", proxyV2(0x0, 0x00, nil), "", false},
+		{"v2 udp", proxyV2(0x1, 0x12, v4), "", false},
+		{"v2 short tcp4", proxyV2(0x1, 0x11, v4[:8]), "", true},
+		{"v2 short tcp6", proxyV2(0x1, 0x21, v6[:20]), "", true},
+		{"v2 truncated", proxyV2(0x1, 0x11, v4)[:20], "", true},
+		{"v2 bad command", proxyV2(0x2, 0x11, v4), "", true},
+		{"v2 bad version", strings.Replace(proxyV2(0x1, 0x11, v4), "\x21", "\x11", 1), "", true},
+		{"ssh banner", "SSH-2.0-OpenSSH_9.6\r\n", "", true},
+		{"empty", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := parseProxyHeader(bufio.NewReader(strings.NewReader(tt.header)))
+			if tt.err {
+				if err == nil {
+					t.Fatalf("got %v, want an error", addr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if addr != nil {
+				got = addr.String()
+			}
+			if got != tt.want {
+				t.Errorf("address = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// The header is taken off the stream, whatever follows is the client's
+func TestProxyConn(t *testing.T) {
+	tests := []struct {
+		name   string
+		trust  string
+		remote string
+		read   string
+	}{
+		{"trusted", "127.0.0.0/8", "203.0.113.7", "SSH-2.0-client\r\n"},
+		{"untrusted", "192.0.2.0/24", "127.0.0.1", "PROXY TCP4 203.0.113.7 10.0.0.1 51000 22\r\nSSH-2.0-client\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ln, err := newProxyListener(inner, ProxyConfig{Trusted: []string{tt.trust}, HeaderTimeout: Duration(time.Second)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ln.Close()
+
+			client, err := net.Dial("tcp", ln.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+			go io.WriteString(client, "PROXY TCP4 203.0.113.7 10.0.0.1 51000 22\r\nSSH-2.0-client\r\n")
+
+			conn, err := ln.Accept()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			if got := remoteIP(conn.RemoteAddr()); got != tt.remote {
+				t.Errorf("remote = %q, want %q", got, tt.remote)
+			}
+			buf := make([]byte, len(tt.read))
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			if _, err := io.ReadFull(conn, buf); err != nil {
+				t.Fatal(err)
+			}
+			if string(buf) != tt.read {
+				t.Errorf("read %q, want %q", buf, tt.read)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"path/filepath"
+	"time"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/recording"
+)
+
+// how often old recordings are looked for
+const recordingPruneInterval = time.Hour
+
+// startRecording opens a recording of a session's output when recording is
+// enabled and the session is sampled, nil otherwise
+func (srv *Server) startRecording(t terminal, start time.Time) *recording.Writer {
+	config := srv.config().Recording
+	if config.Dir == "" || t.owner || rand.Float64() >= config.SampleRate {
+		return nil
+	}
+
+	id := t.id
+	if len(id) > 12 {
+		id = id[:12]
+	}
+	path := filepath.Join(config.Dir, fmt.Sprintf("%s-%s%s", start.UTC().Format("20060102-150405"), id, recording.Extension))
+	rec, err := recording.Create(path, recording.Header{
+		Width:     t.width,
+		Height:    t.height,
+		Timestamp: start.Unix(),
+		Title:     fmt.Sprintf("%s session %s", t.transport, id),
+		Env:       map[string]string{"TERM": t.term},
+	})
+	if err != nil {
+		t.logger.Error("failed to start recording", "error", err)
+		return nil
+	}
+	t.logger.Info("recording session", "file", path)
+	return rec
+}
+
+// pruneRecordings applies the recording retention limits now and every
+// recordingPruneInterval
+func (srv *Server) pruneRecordings() {
+	for {
+		config := srv.config().Recording
+		if config.Dir != "" {
+			n, err := recording.Prune(config.Dir, time.Duration(config.MaxAge), config.MaxFiles)
+			if err != nil {
+				slog.Error("failed to prune recordings", "dir", config.Dir, "error", err)
+			} else if n > 0 {
+				slog.Info("pruned recordings", "dir", config.Dir, "deleted", n)
+			}
+		}
+		time.Sleep(recordingPruneInterval)
+	}
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/tui"
+)
+
+// SessionInfo describes a live TUI session
+type SessionInfo struct {
+	ID        string    `json:"id"`
+	User      string    `json:"user"`
+	IP        string    `json:"ip"`
+	Transport string    `json:"transport"`
+	Term      string    `json:"term"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	Section   string    `json:"section"`
+	Started   time.Time `json:"started"`
+}
+
+// sessionRegistry tracks the running TUI programs so the owner can see and
+// act on them
+type sessionRegistry struct {
+	mu       sync.Mutex
+	sessions map[string]*liveSession
+}
+
+type liveSession struct {
+	program *tea.Program
+
+	mu   sync.Mutex // guards info, updated by the session's goroutines
+	info SessionInfo
+}
+
+func newSessionRegistry() *sessionRegistry {
+	return &sessionRegistry{sessions: make(map[string]*liveSession)}
+}
+
+// add registers a session until the returned func is called
+func (r *sessionRegistry) add(live *liveSession) func() {
+	id := live.info.ID
+
+	r.mu.Lock()
+	r.sessions[id] = live
+	r.mu.Unlock()
+
+	return func() {
+		r.mu.Lock()
+		delete(r.sessions, id)
+		r.mu.Unlock()
+	}
+}
+
+// count returns the number of live sessions
+func (r *sessionRegistry) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.sessions)
+}
+
+// list returns the live sessions, oldest first
+func (r *sessionRegistry) list() []SessionInfo {
+	r.mu.Lock()
+	infos := make([]SessionInfo, 0, len(r.sessions))
+	for _, live := range r.sessions {
+		infos = append(infos, live.snapshot())
+	}
+	r.mu.Unlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Started.Before(infos[j].Started)
+	})
+	return infos
+}
+
+// send delivers a message to the program of a session, reporting false
+// when the session is gone
+func (r *sessionRegistry) send(id string, msg tea.Msg) bool {
+	r.mu.Lock()
+	live, ok := r.sessions[id]
+	r.mu.Unlock()
+	if !ok {
+		return false
+	}
+	// Send returns at once when the program has already finished
+	live.program.Send(msg)
+	return true
+}
+
+// sendIP delivers a message to every session from ip and returns how many
+// there were
+func (r *sessionRegistry) sendIP(ip string, msg tea.Msg) int {
+	var programs []*tea.Program
+	r.mu.Lock()
+	for _, live := range r.sessions {
+		if live.snapshot().IP == ip {
+			programs = append(programs, live.program)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, p := range programs {
+		p.Send(msg)
+	}
+	return len(programs)
+}
+
+// sendAll delivers a message to every session and returns how many there
+// were. Programs are sent to concurrently so a busy one delays no other.
+func (r *sessionRegistry) sendAll(msg tea.Msg) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, live := range r.sessions {
+		go live.program.Send(msg)
+	}
+	return len(r.sessions)
+}
+
+func (l *liveSession) snapshot() SessionInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.info
+}
+
+func (l *liveSession) resized(width, height int) {
+	l.mu.Lock()
+	l.info.Width, l.info.Height = width, height
+	l.mu.Unlock()
+}
+
+// observe keeps the current section up to date, as an OnEvent hook
+func (l *liveSession) observe(e tui.Event) {
+	if e.Kind != tui.EventSectionEnter {
+		return
+	}
+	l.mu.Lock()
+	l.info.Section = e.Section
+	l.mu.Unlock()
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+// reloadConfig builds the configuration again and applies it without a
+// restart: limits, access lists, owner and private section keys, content
+// path, log level and TUI features. Nothing is applied when the new
+// configuration is invalid.
+// It returns the changed settings that only take effect on restart.
+func (srv *Server) reloadConfig() ([]string, error) {
+	current := srv.config()
+	if current.Reload == nil {
+		return nil, errors.New("reloading is not available")
+	}
+	next, err := current.Reload()
+	if err != nil {
+		return nil, err
+	}
+	next.Reload = current.Reload
+
+	// everything that can fail is checked before anything changes
+	var level slog.LevelVar
+	if err := level.UnmarshalText([]byte(next.Log.Level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: %w", next.Log.Level, err)
+	}
+	var portfolio models.Portfolio
+	contentChanged := next.ContentPath != current.ContentPath
+	if contentChanged {
+		if portfolio, err = models.LoadPortfolio(next.ContentPath); err != nil {
+			return nil, err
+		}
+	}
+	owners, err := readOwners(next.Admin.AuthorizedKeys)
+	if err != nil {
+		return nil, err
+	}
+	privateKeys, err := readPrivateKeys(next.Private.AuthorizedKeys)
+	if err != nil {
+		return nil, err
+	}
+	// the last check, it applies the access settings when they are valid
+	if err := srv.access.reconfigure(next.Access); err != nil {
+		return nil, err
+	}
+
+	srv.setOwners(owners)
+	srv.setPrivateKeys(privateKeys)
+	srv.limiter.reconfigure(next.Limits)
+	logLevel.Set(level.Level())
+	srv.cfg.Store(&next)
+	if contentChanged {
+		srv.setContent(portfolio)
+	}
+
+	restart := restartRequired(current, &next)
+	slog.Info("config reloaded", "restart_required", restart)
+	return restart, nil
+}
+
+// restartRequired lists the settings that differ between two configs but
+// are only read when the server starts
+func restartRequired(old, next *Config) []string {
+	oldLog, nextLog := old.Log, next.Log
+	oldLog.Level, nextLog.Level = "", ""
+
+	settings := []struct {
+		name      string
+		old, next any
+	}{
+		{"listen_addr", old.ListenAddrs, next.ListenAddrs},
+		{"host_keys", old.HostKeys, next.HostKeys},
+		{"access.ban_file", old.Access.BanFile, next.Access.BanFile},
+		{"proxy_protocol", old.Proxy, next.Proxy},
+		{"log", oldLog, nextLog},
+		{"metrics", old.Metrics, next.Metrics},
+		{"health", old.Health, next.Health},
+		{"web", old.Web, next.Web},
+		{"finger", old.Finger, next.Finger},
+		{"gemini", old.Gemini, next.Gemini},
+		{"gopher", old.Gopher, next.Gopher},
+		{"telnet", old.Telnet, next.Telnet},
+		{"analytics", old.Analytics, next.Analytics},
+		{"visitors", old.Visitors, next.Visitors},
+		{"control", old.Control, next.Control},
+	}
+
+	var changed []string
+	for _, s := range settings {
+		if !reflect.DeepEqual(s.old, s.next) {
+			changed = append(changed, s.name)
+		}
+	}
+	return changed
+}
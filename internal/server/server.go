@@ -1,176 +1,595 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	ssh "github.com/charmbracelet/ssh"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"github.com/cankurttekin/sh.kurttekin.com/internal/analytics"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/invites"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
 	"github.com/cankurttekin/sh.kurttekin.com/internal/tui"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/visitors"
 )
 
-// ssh server configuration
-type Config struct {
-	ListenAddr string
-	KeyPath    string
-	LogFile    string
-}
+// Server serves the portfolio TUI to SSH sessions
+type Server struct {
+	cfg        atomic.Pointer[Config] // replaced as a whole on reload, read with config()
+	limiter    *limiter
+	access     *accessControl
+	analytics  *analytics.Store // nil when analytics are disabled
+	visitorKey []byte           // secret that keyless visitor IDs are keyed with
+	visitors   *visitors.Store  // nil when returning visitors are not tracked
+	started    time.Time
+	sessions   *sessionRegistry
 
-func DefaultConfig() Config {
-	defaultLogPath := "tuiserver_connections.log"
+	// keys that unlock admin mode and private sections, reloaded with the
+	// config
+	keysMu      sync.RWMutex
+	owners      []ssh.PublicKey
+	privateKeys []ssh.PublicKey
 
-	return Config{
-		ListenAddr: ":2222",
-		LogFile:    defaultLogPath,
-	}
-}
+	// the control socket, and the signals it can raise like kill would
+	control net.Listener
+	signals chan os.Signal
 
-func setupLogger(logFilePath string) (*os.File, error) {
-	if logFilePath == "" {
-		return nil, nil
-	}
+	// banner shown to every session while set
+	maintenanceMu sync.Mutex
+	maintenance   string
 
-	logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open log file: %w", err)
-	}
+	// content served to new sessions, replaced when an owner saves edits,
+	// and its number in the visitor store
+	contentMu sync.RWMutex
+	portfolio models.Portfolio
+	revision  uint64
+
+	// visitor count shown by templates, see visitorCount
+	visitorCountMu sync.Mutex
+	visitorCountN  int
+	visitorCountAt time.Time
+
+	httpMuxes   map[string]*http.ServeMux
+	httpServers []*http.Server
+
+	// finger, gopher, gemini, telnet and control listeners, closed on shutdown
+	protocolListeners []net.Listener
 
-	multiWriter := io.MultiWriter(os.Stdout, logFile)
+	// sockets passed by systemd socket activation or by the process we
+	// upgraded from, by service name
+	activated map[string][]net.Listener
 
-	log.SetOutput(multiWriter)
+	// every listening socket, handed to the new process on upgrade
+	bound []boundListener
 
-	log.SetFlags(log.LstdFlags | log.Lmicroseconds)
+	// browser and telnet sessions and control requests are drained on
+	// shutdown like SSH sessions, and hangup is canceled when the shutdown
+	// timeout cuts them off
+	streamSessions sync.WaitGroup
+	streamMu       sync.Mutex // orders startStream against the Wait in drain
+	streamsClosed  bool
+	hangup         context.Context
+	hangupAll      context.CancelFunc
 
-	return logFile, nil
+	// readiness state reported on /readyz
+	listening      atomic.Bool
+	hostKeysLoaded atomic.Bool
+	draining       atomic.Bool
 }
 
 func Start(config Config) error {
-	logFile, err := setupLogger(config.LogFile)
+	logFile, err := setupLogger(config.Log)
 	if err != nil {
-		log.Printf("Warning: Could not set up file logging to %s: %v. Continuing with stdout only.", config.LogFile, err)
+		return err
 	}
 
 	// close the log file when the server stops
 	if logFile != nil {
 		defer logFile.Close()
-		log.Printf("Logging connections to file: %s", config.LogFile)
-	} else if config.LogFile != "" {
-		log.Printf("Failed to open log file: %s - logs will only be shown in stdout", config.LogFile)
+		slog.Info("logging connections to file", "file", config.Log.File)
+		go reopenOnHangup(logFile)
 	}
 
-	// check if the port is already in use
-	if isPortInUse(config.ListenAddr) {
-		log.Printf("Port %s is already in use. Try stopping existing SSH server or using a different port.", config.ListenAddr)
-		return fmt.Errorf("port %s is already in use", config.ListenAddr)
+	portfolio, err := models.LoadPortfolio(config.ContentPath)
+	if err != nil {
+		return err
 	}
-
-	server := ssh.Server{
-		Addr:    config.ListenAddr,
-		Handler: handleSession,
+	tui.SetTheme(portfolio.Theme)
+	if config.ContentPath != "" {
+		slog.Info("loaded content", "file", config.ContentPath)
 	}
 
-	log.Printf("SSH server started on %s\n", config.ListenAddr)
-	return server.ListenAndServe()
-}
+	access, err := newAccessControl(config.Access)
+	if err != nil {
+		return err
+	}
 
-func isPortInUse(addr string) bool {
-	// extract port from listen address
-	host, port, err := net.SplitHostPort(addr)
+	srv := &Server{
+		portfolio: portfolio,
+		limiter:   newLimiter(config.Limits),
+		access:    access,
+		sessions:  newSessionRegistry(),
+		started:   time.Now(),
+		signals:   make(chan os.Signal, 1),
+	}
+	srv.cfg.Store(&config)
+	owners, err := readOwners(config.Admin.AuthorizedKeys)
+	if err != nil {
+		return err
+	}
+	srv.setOwners(owners)
+	privateKeys, err := readPrivateKeys(config.Private.AuthorizedKeys)
 	if err != nil {
-		if strings.HasPrefix(addr, ":") {
-			port = addr[1:]
-			host = "localhost"
-		} else {
-			return false
+		return err
+	}
+	srv.setPrivateKeys(privateKeys)
+	srv.hangup, srv.hangupAll = context.WithCancel(context.Background())
+	if srv.activated, err = activatedListeners(); err != nil {
+		return err
+	}
+	if config.Analytics.File != "" {
+		if srv.visitorKey, err = ensureVisitorKey(config.Analytics.File + ".key"); err != nil {
+			return err
 		}
+		srv.analytics = analytics.NewStore(config.Analytics.File)
+	}
+	go srv.pruneRecordings()
+	if config.Visitors.File != "" {
+		srv.visitors = visitors.NewStore(config.Visitors.File)
+		srv.revision = srv.addRevision(portfolio)
+	}
+
+	if addr := config.Metrics.ListenAddr; addr != "" {
+		srv.handleHTTP(addr, "/metrics", promhttp.Handler())
+	}
+	if addr := config.Health.ListenAddr; addr != "" {
+		srv.handleHTTP(addr, "/healthz", http.HandlerFunc(srv.handleHealthz))
+		srv.handleHTTP(addr, "/readyz", http.HandlerFunc(srv.handleReadyz))
+		srv.handleHTTP(addr, "/version", http.HandlerFunc(srv.handleVersion))
+	}
+	if addr := config.Web.ListenAddr; addr != "" {
+		srv.handleHTTP(addr, "/", http.HandlerFunc(srv.handlePortfolio))
+		switch {
+		case config.Web.Terminal && !hasXtermAssets():
+			slog.Warn("web terminal disabled, xterm.js is not built in, see internal/server/xterm/README.md")
+		case config.Web.Terminal:
+			srv.handleHTTP(addr, "/terminal", http.HandlerFunc(srv.handleTerminalPage))
+			srv.handleHTTP(addr, "/terminal/assets/", http.HandlerFunc(srv.handleTerminalAssets))
+			srv.handleHTTP(addr, "/terminal/ws", http.HandlerFunc(srv.handleTerminalSocket))
+		}
+	}
+	if err := srv.startHTTP(); err != nil {
+		return err
+	}
+	if err := srv.startFinger(); err != nil {
+		return err
 	}
-	if host == "" {
-		host = "localhost"
+	if err := srv.startGemini(); err != nil {
+		return err
 	}
+	if err := srv.startGopher(); err != nil {
+		return err
+	}
+	if err := srv.startTelnet(); err != nil {
+		return err
+	}
+
+	server := srv.sshServer()
 
-	ln, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	for _, keyPath := range config.HostKeys {
+		if err := ensureHostKey(keyPath); err != nil {
+			return err
+		}
+		if err := server.SetOption(ssh.HostKeyFile(keyPath)); err != nil {
+			return fmt.Errorf("failed to load host key %s: %w", keyPath, err)
+		}
+	}
+	srv.hostKeysLoaded.Store(true)
+
+	listeners, err := srv.listen("ssh", config.ListenAddrs...)
 	if err != nil {
+		return err
+	}
+	if len(listeners) == 0 {
+		return errors.New("no SSH listen address configured")
+	}
+	if len(config.Proxy.Trusted) > 0 {
+		for i, ln := range listeners {
+			if listeners[i], err = newProxyListener(ln, config.Proxy); err != nil {
+				return err
+			}
+		}
+		slog.Info("accepting PROXY protocol headers", "trusted", config.Proxy.Trusted)
+	}
+	// last, so the sockets listed by status are all bound
+	if err := srv.startControl(); err != nil {
+		return err
+	}
+	srv.listening.Store(true)
+	// after an upgrade the service is running already, the old process
+	// hands it over
+	if !notifyUpgradeParent() {
+		notifyServiceManager("READY=1")
+	}
+
+	done := make(chan struct{})
+	go srv.shutdownOnSignal(server, done)
+
+	errs := make(chan error, len(listeners))
+	for _, ln := range listeners {
+		slog.Info("SSH server started", "addr", ln.Addr().String())
+		go func(ln net.Listener) {
+			errs <- server.Serve(ln)
+		}(ln)
+	}
+
+	// every Serve returns once the server is shut down, a listener failing
+	// on its own takes the others down with it
+	var serveErr error
+	for range listeners {
+		if err := <-errs; !errors.Is(err, ssh.ErrServerClosed) && serveErr == nil {
+			serveErr = err
+			server.Close()
+		}
+	}
+	srv.listening.Store(false)
+	if serveErr != nil {
+		return serveErr
+	}
+
+	// wait for the remaining sessions to drain
+	<-done
+	return nil
+}
+
+// shutdownOnSignal drains the server on SIGINT or SIGTERM. Readiness turns
+// false first, then the listener closes and running sessions get up to
+// shutdown_timeout to finish before they are cut off.
+//
+// SIGUSR2 upgrades instead: a new process is started with the listening
+// sockets and, once it serves, this one stops accepting and lets running
+// sessions finish within upgrade_drain_timeout.
+func (srv *Server) shutdownOnSignal(server *ssh.Server, done chan<- struct{}) {
+	defer close(done)
+
+	// the control socket sends to the same channel
+	sig := srv.signals
+	signal.Notify(sig, append([]os.Signal{syscall.SIGINT, syscall.SIGTERM}, upgradeSignals...)...)
+
+	received := <-sig
+	for isUpgradeSignal(received) {
+		slog.Info("upgrading, starting a new process", "signal", received.String())
+		if err := srv.upgrade(); err != nil {
+			slog.Error("upgrade failed, still serving", "error", err)
+			received = <-sig
+			continue
+		}
+		signal.Stop(sig)
+
+		// the new process listens on the control socket path now
+		if ul, ok := srv.control.(*net.UnixListener); ok {
+			ul.SetUnlinkOnClose(false)
+		}
+
+		srv.draining.Store(true)
+		slog.Info("new process is serving, draining sessions", "timeout", time.Duration(srv.config().UpgradeDrainTimeout))
+
+		// the new process answers HTTP from now on
+		httpCtx, httpCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer httpCancel()
+		srv.shutdownHTTP(httpCtx)
+
+		srv.drain(server, time.Duration(srv.config().UpgradeDrainTimeout))
+		slog.Info("old process stopped")
+		return
+	}
+	signal.Stop(sig)
+
+	srv.draining.Store(true)
+	notifyServiceManager("STOPPING=1")
+	slog.Info("shutting down", "signal", received.String(), "timeout", time.Duration(srv.config().ShutdownTimeout))
+
+	srv.drain(server, time.Duration(srv.config().ShutdownTimeout))
+
+	httpCtx, httpCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer httpCancel()
+	srv.shutdownHTTP(httpCtx)
+	slog.Info("server stopped")
+}
+
+// drain stops accepting connections and gives running sessions up to
+// timeout to finish before they are cut off
+func (srv *Server) drain(server *ssh.Server, timeout time.Duration) {
+	for _, ln := range srv.protocolListeners {
+		ln.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("sessions still open after shutdown timeout, closing them", "error", err)
+		server.Close()
+	}
+	srv.closeStreams()
+	if !srv.waitStreamSessions(ctx) {
+		slog.Warn("browser and telnet sessions still open after shutdown timeout, closing them")
+		srv.hangupAll()
+	}
+}
+
+// startStream counts a browser or telnet session or a control request
+// towards the drain, reporting false once the drain has begun and the
+// session must be turned away. Done must be called when it ends.
+func (srv *Server) startStream() bool {
+	srv.streamMu.Lock()
+	defer srv.streamMu.Unlock()
+	if srv.streamsClosed {
+		return false
+	}
+	srv.streamSessions.Add(1)
+	return true
+}
+
+// closeStreams makes startStream refuse new sessions, so none is added
+// while the drain waits
+func (srv *Server) closeStreams() {
+	srv.streamMu.Lock()
+	defer srv.streamMu.Unlock()
+	srv.streamsClosed = true
+}
+
+// waitStreamSessions waits for browser and telnet sessions to end,
+// reporting false when ctx expires first
+func (srv *Server) waitStreamSessions(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		srv.streamSessions.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
 		return true
+	case <-ctx.Done():
+		return false
 	}
+}
 
-	// port is available, close the listener
-	ln.Close()
-	return false
+// reopenOnHangup reopens the log file on SIGHUP, after logrotate moved it
+func reopenOnHangup(logFile *rotatingFile) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		if err := logFile.Reopen(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to reopen log file: %v\n", err)
+			continue
+		}
+		slog.Info("reopened log file")
+	}
 }
 
-// handleSession is called when a new SSH session is established
-func handleSession(s ssh.Session) {
-	remoteAddr := s.RemoteAddr().String()
-	sessionID := s.Context().SessionID()
-	username := s.User()
+// config returns the current configuration, which must not be modified
+func (srv *Server) config() *Config {
+	return srv.cfg.Load()
+}
+
+// sshServer is the SSH server sessions are served on, without host keys
+func (srv *Server) sshServer() *ssh.Server {
+	return &ssh.Server{
+		Handler:                    srv.handleSession,
+		ConnCallback:               srv.checkConn,
+		ConnectionFailedCallback:   srv.connFailed,
+		ServerConfigCallback:       sshConfig,
+		KeyboardInteractiveHandler: acceptKeyboardInteractive,
+	}
+}
 
+// limitedConnKey holds a connection's *limitedConn in its ssh.Context
+type limitedConnKey struct{}
+
+// limitedConn is an SSH connection holding a limiter slot until it closes
+type limitedConn struct {
+	net.Conn
+	release func()
+
+	mu        sync.Mutex
+	handshake time.Time // deadline until the first session opens, zero after
+}
+
+func (c *limitedConn) Close() error {
+	c.release()
+	return c.Conn.Close()
+}
+
+// SetDeadline keeps the handshake deadline until the handshake is done,
+// the SSH server sets a deadline before every read and write
+func (c *limitedConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.handshake.IsZero() && (t.IsZero() || t.After(c.handshake)) {
+		t = c.handshake
+	}
+	return c.Conn.SetDeadline(t)
+}
+
+// handshakeDone lifts the handshake deadline
+func (c *limitedConn) handshakeDone() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.handshake.IsZero() {
+		c.handshake = time.Time{}
+		c.Conn.SetDeadline(time.Time{})
+	}
+}
+
+// checkConn drops denied and banned addresses and connections over the
+// limits before the SSH handshake, and gives the handshake
+// handshake_timeout to get to a session
+func (srv *Server) checkConn(ctx ssh.Context, conn net.Conn) net.Conn {
+	// a load balancer that sent no header is misconfigured, not an attacker
+	if pc, ok := conn.(*proxyConn); ok && pc.headerFailed() {
+		return nil
+	}
+
+	ip := remoteIP(conn.RemoteAddr())
+	if ok, reason := srv.access.check(ip); !ok {
+		slog.Info("connection refused", "reason", reason, "ip", ip)
+		rejectionsTotal.WithLabelValues(reason).Inc()
+		return nil
+	}
+	release, reason := srv.limiter.connect(ip)
+	if release == nil {
+		slog.Warn("connection rejected", "reason", reason, "ip", ip)
+		rejectionsTotal.WithLabelValues(reason).Inc()
+		rejectConn(conn, reason)
+		return nil
+	}
+
+	lc := &limitedConn{Conn: conn, release: release}
+	if timeout := time.Duration(srv.config().Limits.HandshakeTimeout); timeout > 0 {
+		lc.handshake = time.Now().Add(timeout)
+		conn.SetDeadline(lc.handshake)
+	}
+	ctx.SetValue(limitedConnKey{}, lc)
+	return lc
+}
+
+// rejectConn writes a polite explanation to a connection that is turned
+// away before the handshake. SSH clients show lines that come before the
+// server's version line, so the visitor still learns why.
+func rejectConn(conn net.Conn, reason string) {
+	conn.SetWriteDeadline(time.Now().Add(time.Second))
+	fmt.Fprintf(conn, "%s\r\n", rejectMessages[reason])
+}
+
+// handshakeDone lifts the handshake timeout of the session's connection
+func handshakeDone(s ssh.Session) {
+	if lc, ok := s.Context().Value(limitedConnKey{}).(*limitedConn); ok {
+		lc.handshakeDone()
+	}
+}
+
+// connFailed counts failed handshakes, typically port scanners, towards a ban
+func (srv *Server) connFailed(conn net.Conn, err error) {
+	srv.access.strike(remoteIP(conn.RemoteAddr()), "failed handshake")
+}
+
+// reject writes a polite explanation to a session that is turned away
+func reject(s ssh.Session, reason string) {
+	fmt.Fprintf(s, "%s\r\n", rejectMessages[reason])
+	s.Exit(1)
+}
+
+// handleSession is called when a new SSH session is established
+func (srv *Server) handleSession(s ssh.Session) {
+	handshakeDone(s)
+	ip := remoteIP(s.RemoteAddr())
 	startTime := time.Now()
-	log.Printf("+ Connection opened | User: %s | IP: %s | Session: %s | Time: %s",
-		username, remoteAddr, sessionID, startTime.Format(time.RFC3339))
+
+	// every line logged for this session carries the same attributes, an
+	// invite code given as user name would be usable by anyone reading them
+	user := invites.Redact(s.User())
+	logger := slog.With(
+		"session", s.Context().SessionID(),
+		"user", user,
+		"ip", ip,
+	)
+	logger.Info("connection opened")
+
+	// the connection was rate limited before its handshake, commands take
+	// a session slot like the TUI
+	release, reason := srv.limiter.session(ip)
+	if release == nil {
+		reject(s, reason)
+		logger.Warn("connection rejected", "reason", reason)
+		rejectionsTotal.WithLabelValues(reason).Inc()
+		return
+	}
+	defer release()
+
+	if args := s.Command(); len(args) > 0 {
+		srv.handleCommand(s, logger, args)
+		return
+	}
 
 	// check if we have a valid PTY
 	pty, windowChange, isPty := s.Pty()
 	if !isPty {
 		fmt.Fprintln(s, "No active terminal, please run with ssh -t")
-		log.Printf("Connection closed (no PTY) | User: %s | IP: %s | Session: %s | Duration: %s",
-			username, remoteAddr, sessionID, time.Since(startTime))
+		srv.access.strike(ip, "no PTY")
+		rejectionsTotal.WithLabelValues("no_pty").Inc()
+		logger.Info("connection closed", "reason", "no PTY", "duration", time.Since(startTime))
 		return
 	}
 
-	// logging terminal details 
-	log.Printf("Terminal info | Term: %s | Size: %dx%d | Session: %s",
-		pty.Term, pty.Window.Width, pty.Window.Height, sessionID)
-
-	// clear the screen and hide the cursor
-	fmt.Fprint(s, "\033[2J\033[H\033[?25l") 
-
-	// initialize model with term dimensions
-	m := tui.NewModel(pty.Window.Width, pty.Window.Height)
-
-	p := tea.NewProgram(
-		m,
-		tea.WithAltScreen(),       // Use alternate screen buffer
-		tea.WithInput(s),          // Use SSH session for input
-		tea.WithOutput(s),         // Use SSH session for output
-		tea.WithMouseCellMotion(), // Enable mouse support
-	)
-
-	// handle window resizing events
+	// forward window changes to the TUI
+	resize := make(chan tea.WindowSizeMsg)
 	go func() {
-		for {
+		defer close(resize)
+		for w := range windowChange {
+			width, height := clampSize(w.Width, w.Height)
 			select {
+			case resize <- tea.WindowSizeMsg{Width: width, Height: height}:
 			case <-s.Context().Done():
 				return
-			case w := <-windowChange:
-				p.Send(tea.WindowSizeMsg{
-					Width:  w.Width,
-					Height: w.Height,
-				})
-				log.Printf("Terminal resize | Session: %s | New size: %dx%d",
-					sessionID, w.Width, w.Height)
 			}
 		}
 	}()
 
-	if _, err := p.Run(); err != nil {
-		// show cursor again before displaying error
-		fmt.Fprint(s, "\033[?25h")
-		fmt.Fprintf(s, "Error running TUI: %v\n", err)
-		log.Printf("Error | Session: %s | User: %s | Error: %v",
-			sessionID, username, err)
-	} else {
-		// show cursor again before exiting
-		fmt.Fprint(s, "\033[?25h")
-	}
-
-	// logging connection termination
-	duration := time.Since(startTime)
-	log.Printf("- Connection closed | Session: %s | User: %s | IP: %s | Duration: %s",
-		sessionID, username, remoteAddr, duration)
+	key := verifiedKey(s.Context())
+	width, height := clampSize(pty.Window.Width, pty.Window.Height)
+	t := terminal{
+		ctx:         s.Context(),
+		id:          s.Context().SessionID(),
+		transport:   "ssh",
+		user:        user,
+		ip:          ip,
+		fingerprint: keyFingerprint(key),
+		owner:       srv.isOwner(key),
+		private:     srv.isPrivateKey(key),
+		term:        pty.Term,
+		tz:          sessionEnv(s, "TZ"),
+		width:       width,
+		height:      height,
+		rw:          s,
+		resize:      resize,
+		logger:      logger,
+	}
+	if invites.IsCode(s.User()) {
+		t.invite = s.User()
+	}
+	if t.owner && s.User() == srv.config().Admin.ConsoleUser {
+		srv.runConsole(t)
+		return
+	}
+	srv.runTUI(t, startTime)
+}
+
+// sessionEnv returns a variable the client sent with the session, "" when
+// it sent none
+func sessionEnv(s ssh.Session, name string) string {
+	for _, kv := range s.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok && k == name {
+			return v
+		}
+	}
+	return ""
+}
+
+func formatSize(width, height int) string {
+	return fmt.Sprintf("%dx%d", width, height)
 }
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStartStream(t *testing.T) {
+	srv := newTestServer(t, testConfig())
+
+	if !srv.startStream() {
+		t.Fatal("stream refused before the drain")
+	}
+	srv.closeStreams()
+	if srv.startStream() {
+		t.Error("stream started after the drain began")
+		srv.streamSessions.Done()
+	}
+
+	// the stream from before the drain is still waited for
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if srv.waitStreamSessions(ctx) {
+		t.Error("drain finished while a stream was running")
+	}
+	srv.streamSessions.Done()
+	if !srv.waitStreamSessions(context.Background()) {
+		t.Error("drain did not finish after the last stream ended")
+	}
+}
+
+// Streams arriving while the drain waits are refused rather than added to
+// the WaitGroup, run with -race
+func TestStartStreamDuringDrain(t *testing.T) {
+	srv := newTestServer(t, testConfig())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if srv.startStream() {
+					srv.streamSessions.Done()
+				}
+			}
+		}()
+	}
+	srv.closeStreams()
+	if !srv.waitStreamSessions(context.Background()) {
+		t.Error("drain did not finish")
+	}
+	wg.Wait()
+}
@@ -0,0 +1,243 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/tui"
+)
+
+// terminal is a client the TUI runs on, whatever transport it came over.
+// Limits and access checks are done by the caller before runTUI.
+type terminal struct {
+	ctx         context.Context // done when the client goes away
+	id          string
+	transport   string // "ssh", "web" or "telnet"
+	user        string // user name, with an invite code redacted
+	invite      string // invite code the visitor gave as user name, never logged
+	ip          string
+	fingerprint string // SHA256 key fingerprint, "" when unknown
+	owner       bool   // authenticated with an owner key
+	private     bool   // authenticated with a key that sees private sections
+	term        string
+	tz          string // TZ the client sent, "" when none
+	width       int
+	height      int
+	rw          io.ReadWriter            // keystrokes in, screen out
+	resize      <-chan tea.WindowSizeMsg // closed or abandoned when the client goes away
+	logger      *slog.Logger
+}
+
+// largest terminal size a client may report, anything bigger is cut down
+// so a made up size cannot make every render arbitrarily expensive
+const (
+	maxTerminalWidth  = 500
+	maxTerminalHeight = 200
+)
+
+// clampSize limits a terminal size reported by a client
+func clampSize(width, height int) (int, int) {
+	return min(width, maxTerminalWidth), min(height, maxTerminalHeight)
+}
+
+// runTUI runs the portfolio TUI on a terminal until the visitor quits, times
+// out or disconnects, then records the session
+func (srv *Server) runTUI(t terminal, startTime time.Time) {
+	logger := t.logger.With("term", t.term, "size", formatSize(t.width, t.height))
+	logger.Info("terminal attached")
+
+	sessionsTotal.Inc()
+	activeSessions.Inc()
+	defer activeSessions.Dec()
+	terminalTypes.WithLabelValues(termLabel(t.term)).Inc()
+	terminalWidth.Observe(float64(t.width))
+	terminalHeight.Observe(float64(t.height))
+
+	// clear the screen and hide the cursor
+	fmt.Fprint(t.rw, "\033[2J\033[H\033[?25l")
+
+	// initialize model with term dimensions. Visitors get the variant for
+	// their user name, private sections only with an allowed key or an
+	// invite, and template expressions rendered; owners get everything,
+	// hidden sections, audience tags and templates included, unless they
+	// connect as an audience to preview it.
+	content, revision := srv.contentRevision()
+	invited, inviteMessage := srv.useInvite(t)
+	private := t.owner || t.private || invited
+	view := func(p models.Portfolio) models.Portfolio {
+		if !private {
+			p = p.Public()
+		}
+		return p.Visible().ForAudience(t.user)
+	}
+	render := srv.renderLine(&t)
+	owner := t.owner && content.AudienceNamed(strings.ToLower(t.user)) == nil
+	if owner {
+		view = func(p models.Portfolio) models.Portfolio { return p }
+		render = func(line string) string { return line }
+	}
+	m := tui.NewModel(view(content).Render(render), t.width, t.height)
+	m.ShowWelcome = srv.config().Features.WelcomeScreen
+	visit, tracked := srv.welcomeBack(&m, t, revision, view(content), view, render)
+	if inviteMessage != "" {
+		m.StatusMessage = inviteMessage
+	}
+	m.Maintenance = srv.maintenanceBanner()
+	m.IdleTimeout = time.Duration(srv.config().Limits.IdleTimeout)
+	m.TimeoutWarning = time.Duration(srv.config().Limits.IdleWarning)
+	if limit := srv.config().Limits.MaxSessionDuration; limit > 0 {
+		m.Deadline = startTime.Add(time.Duration(limit))
+	}
+
+	if owner {
+		logger.Info("owner session, admin mode available")
+		m.Owner = true
+		m.OnSave = func(p models.Portfolio) error {
+			if err := srv.saveContent(p); err != nil {
+				logger.Error("failed to save content", "error", err)
+				return err
+			}
+			logger.Info("content saved", "file", srv.config().ContentPath, "sections", len(p.Sections))
+			return nil
+		}
+	}
+
+	recorder := newSessionRecorder(srv.visitorKey, t.id, t.user, t.ip, t.fingerprint, t.term, startTime)
+	// the user name and TERM are chosen by the client, the console and
+	// ctl sessions show them
+	live := &liveSession{info: SessionInfo{
+		ID:        t.id,
+		User:      printable(t.user),
+		IP:        t.ip,
+		Transport: t.transport,
+		Term:      printable(t.term),
+		Width:     t.width,
+		Height:    t.height,
+		Started:   startTime,
+	}}
+	m.OnEvent = func(e tui.Event) {
+		srv.observeEvent(e)
+		recorder.record(e)
+		live.observe(e)
+	}
+
+	// the program's output is recorded along with resizes, keystrokes never are
+	var output io.Writer = t.rw
+	resized := live.resized
+	if rec := srv.startRecording(t, startTime); rec != nil {
+		output = io.MultiWriter(t.rw, rec)
+		resized = func(width, height int) {
+			live.resized(width, height)
+			rec.Resize(width, height)
+		}
+		defer func() {
+			if err := rec.Close(); err != nil {
+				logger.Error("recording failed", "error", err)
+			}
+		}()
+	}
+
+	opts := []tea.ProgramOption{
+		tea.WithAltScreen(),    // Use alternate screen buffer
+		tea.WithInput(t.rw),    // Read keystrokes from the client
+		tea.WithOutput(output), // Draw to the client
+		tea.WithContext(t.ctx), // Stop when the client disconnects
+
+		// process signals are handled by the server, which drains sessions
+		tea.WithoutSignalHandler(),
+	}
+	if srv.config().Features.Mouse {
+		opts = append(opts, tea.WithMouseCellMotion()) // Enable mouse support
+	}
+
+	p := tea.NewProgram(m, opts...)
+	live.program = p
+	remove, banner := srv.addSession(live)
+	defer remove()
+	if banner != m.Maintenance {
+		// Send blocks until the program runs
+		go p.Send(tui.MaintenanceMsg(banner))
+	}
+
+	go forwardResizes(t, p, resized)
+
+	exitReason := ""
+	final, err := p.Run()
+	switch {
+	case errors.Is(err, tea.ErrProgramKilled):
+		// the client went away, there is nobody left to write to
+	case err != nil:
+		// show cursor again before displaying error
+		fmt.Fprint(t.rw, "\033[?25h")
+		fmt.Fprintf(t.rw, "Error running TUI: %v\r\n", err)
+		logger.Error("TUI error", "error", err)
+		tuiErrors.Inc()
+		exitReason = "error"
+	default:
+		// show cursor again before exiting
+		fmt.Fprint(t.rw, "\033[?25h")
+		if fm, ok := final.(tui.Model); ok {
+			exitReason = fm.ExitReason
+			switch fm.ExitReason {
+			case tui.ExitIdle:
+				fmt.Fprintf(t.rw, "Disconnected after %s without input. Thanks for stopping by!\r\n",
+					time.Duration(srv.config().Limits.IdleTimeout))
+			case tui.ExitMaxDuration:
+				fmt.Fprint(t.rw, "Session time limit reached. Thanks for stopping by!\r\n")
+			case tui.ExitKicked:
+				fmt.Fprint(t.rw, "Disconnected by the site owner.\r\n")
+			}
+		}
+	}
+	if exitReason == "" {
+		exitReason = "disconnected"
+	}
+
+	if tracked {
+		srv.rememberVisit(t, visit, startTime, revision, live.snapshot().Section)
+	}
+
+	// logging connection termination
+	duration := time.Since(startTime)
+	if duration < time.Duration(srv.config().Access.InstantDisconnect) {
+		srv.access.strike(t.ip, "instant disconnect")
+	}
+	sessionDuration.Observe(duration.Seconds())
+	if srv.analytics != nil {
+		if err := srv.analytics.Save(recorder.finish(exitReason)); err != nil {
+			logger.Error("failed to save analytics", "error", err)
+		}
+	}
+	logger.Info("connection closed", "reason", exitReason, "duration", duration)
+}
+
+// forwardResizes sends the terminal's window changes to its program until
+// the client goes away
+func forwardResizes(t terminal, p *tea.Program, resized func(width, height int)) {
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case w, ok := <-t.resize:
+			if !ok {
+				// the channel is closed when the session ends
+				return
+			}
+			// before the program redraws, so a recording has the new size first
+			if resized != nil {
+				resized(w.Width, w.Height)
+			}
+			p.Send(w)
+			t.logger.Debug("terminal resize", "new_size", formatSize(w.Width, w.Height))
+			resizeEvents.Inc()
+		}
+	}
+}
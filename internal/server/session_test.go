@@ -0,0 +1,67 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	gossh "golang.org/x/crypto/ssh"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+func TestClampSize(t *testing.T) {
+	tests := []struct {
+		width, height int
+		wantW, wantH  int
+	}{
+		{80, 24, 80, 24},
+		{500, 200, 500, 200},
+		{501, 201, 500, 200},
+		{1 << 30, 30, 500, 30},
+		{80, 1 << 30, 80, 200},
+	}
+	for _, tt := range tests {
+		if w, h := clampSize(tt.width, tt.height); w != tt.wantW || h != tt.wantH {
+			t.Errorf("clampSize(%d, %d) = %d, %d, want %d, %d", tt.width, tt.height, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+// A client asking for a huge pty gets the largest size the TUI renders
+func TestSSHTerminalSizeIsClamped(t *testing.T) {
+	config := testConfig()
+	config.Features.WelcomeScreen = false
+	srv := newTestServer(t, config)
+	srv.portfolio = models.DefaultPortfolio()
+	addr := startSSH(t, srv, nil)
+	logs := captureLogs(t)
+
+	client, err := gossh.Dial("tcp", addr, &gossh.ClientConfig{
+		User:            "visitor",
+		Auth:            []gossh.AuthMethod{gossh.PublicKeys(newSigner(t))},
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	session, err := client.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	if err := session.RequestPty("xterm", 100000, 100000, gossh.TerminalModes{}); err != nil {
+		t.Fatal(err)
+	}
+	session.Stdin = strings.NewReader("q")
+	if err := session.Shell(); err != nil {
+		t.Fatal(err)
+	}
+	session.Wait()
+
+	if !strings.Contains(logs.String(), "size=500x200") {
+		t.Errorf("terminal size not clamped to 500x200\n%s", logs)
+	}
+}
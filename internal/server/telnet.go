@@ -0,0 +1,391 @@
+package server
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// telnet commands and options from RFC 854, 857, 858, 1073 and 1091
+const (
+	telnetSE   = 240
+	telnetSB   = 250
+	telnetWILL = 251
+	telnetWONT = 252
+	telnetDO   = 253
+	telnetDONT = 254
+	telnetIAC  = 255
+
+	telnetOptEcho  = 1
+	telnetOptSGA   = 3
+	telnetOptTType = 24
+	telnetOptNAWS  = 31
+
+	telnetTTypeIs   = 0
+	telnetTTypeSend = 1
+)
+
+// telnet clients have no user name and may not report a terminal
+const (
+	telnetUser = "telnet"
+	telnetTerm = "ansi"
+)
+
+// how long a client has to report its window size and terminal type
+const telnetNegotiationTimeout = 2 * time.Second
+
+// startTelnet binds the telnet listener when one is configured
+func (srv *Server) startTelnet() error {
+	listeners, err := srv.listen("telnet", srv.config().Telnet.ListenAddr)
+	if err != nil {
+		return err
+	}
+	srv.protocolListeners = append(srv.protocolListeners, listeners...)
+
+	for _, ln := range listeners {
+		go srv.serveTelnet(ln)
+	}
+	return nil
+}
+
+func (srv *Server) serveTelnet(ln net.Listener) {
+	slog.Info("telnet listener started", "addr", ln.Addr().String())
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			slog.Warn("telnet accept failed", "error", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+		go srv.handleTelnet(conn)
+	}
+}
+
+// handleTelnet negotiates character mode, the window size and the
+// terminal type, then runs the TUI like an SSH session
+func (srv *Server) handleTelnet(conn net.Conn) {
+	defer conn.Close()
+
+	ip := remoteIP(conn.RemoteAddr())
+	if ok, reason := srv.access.check(ip); !ok {
+		slog.Info("connection refused", "reason", reason, "ip", ip, "transport", "telnet")
+		rejectionsTotal.WithLabelValues(reason).Inc()
+		return
+	}
+
+	if !srv.startStream() {
+		fmt.Fprintf(conn, "The server is shutting down, please try again in a moment.\r\n")
+		return
+	}
+	defer srv.streamSessions.Done()
+
+	startTime := time.Now()
+	id := newSessionID()
+
+	// every line logged for this session carries the same attributes
+	logger := slog.With(
+		"session", id,
+		"user", telnetUser,
+		"ip", ip,
+		"transport", "telnet",
+	)
+	logger.Info("connection opened")
+
+	release, reason := srv.limiter.acquire(ip)
+	if release == nil {
+		fmt.Fprintf(conn, "%s\r\n", rejectMessages[reason])
+		logger.Warn("connection rejected", "reason", reason)
+		rejectionsTotal.WithLabelValues(reason).Inc()
+		return
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(srv.hangup)
+	defer cancel()
+	t := newTelnetConn(conn, cancel)
+	t.negotiate(telnetNegotiationTimeout)
+
+	t.mu.Lock()
+	width, height, term := t.width, t.height, t.term
+	t.mu.Unlock()
+	if width == 0 || height == 0 {
+		// the client did not offer NAWS, assume a classic terminal
+		width, height = 80, 24
+	}
+	if term == "" {
+		term = telnetTerm
+	}
+
+	srv.runTUI(terminal{
+		ctx:       ctx,
+		id:        id,
+		transport: "telnet",
+		user:      telnetUser,
+		ip:        ip,
+		term:      term,
+		width:     width,
+		height:    height,
+		rw:        t,
+		resize:    t.startResizes(ctx),
+		logger:    logger,
+	}, startTime)
+}
+
+// telnetConn strips telnet commands from the input, answers option
+// negotiation and escapes IAC bytes in the output
+type telnetConn struct {
+	conn   net.Conn
+	cancel context.CancelFunc // called when the client goes away
+
+	// parser state, only touched by the reading goroutine
+	raw     []byte
+	pending []byte // decoded keystrokes not returned by Read yet
+	state   int
+	verb    byte // WILL, WONT, DO or DONT awaiting its option
+	sub     []byte
+	afterCR bool
+
+	writeMu sync.Mutex
+
+	// reported by the client, guarded by mu
+	mu            sync.Mutex
+	width, height int
+	term          string
+	resize        func(tea.WindowSizeMsg)
+}
+
+// parser states
+const (
+	telnetStateData = iota
+	telnetStateIAC
+	telnetStateOption
+	telnetStateSub
+	telnetStateSubIAC
+)
+
+func newTelnetConn(conn net.Conn, cancel context.CancelFunc) *telnetConn {
+	return &telnetConn{
+		conn:   conn,
+		cancel: cancel,
+		raw:    make([]byte, 1024),
+	}
+}
+
+// negotiate asks for character mode, the window size and the terminal type
+// and reads the replies until both arrived or timeout passes. Keystrokes
+// typed meanwhile are kept for the TUI.
+func (t *telnetConn) negotiate(timeout time.Duration) {
+	t.command(telnetWILL, telnetOptEcho)
+	t.command(telnetWILL, telnetOptSGA)
+	t.command(telnetDO, telnetOptSGA)
+	t.command(telnetDO, telnetOptNAWS)
+	t.command(telnetDO, telnetOptTType)
+
+	t.conn.SetReadDeadline(time.Now().Add(timeout))
+	defer t.conn.SetReadDeadline(time.Time{})
+
+	for {
+		t.mu.Lock()
+		done := t.width > 0 && t.term != ""
+		t.mu.Unlock()
+		if done {
+			return
+		}
+		if err := t.fill(); err != nil {
+			return
+		}
+	}
+}
+
+// startResizes returns the channel NAWS updates are sent to from now on
+func (t *telnetConn) startResizes(ctx context.Context) <-chan tea.WindowSizeMsg {
+	resize := make(chan tea.WindowSizeMsg)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.resize = func(size tea.WindowSizeMsg) {
+		select {
+		case resize <- size:
+		case <-ctx.Done():
+		}
+	}
+	return resize
+}
+
+// Read returns keystrokes with telnet commands removed
+func (t *telnetConn) Read(p []byte) (int, error) {
+	for len(t.pending) == 0 {
+		if err := t.fill(); err != nil {
+			t.cancel()
+			return 0, err
+		}
+	}
+
+	n := copy(p, t.pending)
+	t.pending = t.pending[n:]
+	return n, nil
+}
+
+// fill reads once from the connection and decodes what arrived
+func (t *telnetConn) fill() error {
+	n, err := t.conn.Read(t.raw)
+	for _, b := range t.raw[:n] {
+		t.decode(b)
+	}
+	return err
+}
+
+func (t *telnetConn) decode(b byte) {
+	switch t.state {
+	case telnetStateData:
+		if b == telnetIAC {
+			t.state = telnetStateIAC
+			return
+		}
+		// Enter arrives as CR LF or CR NUL, the TUI expects a lone CR
+		if t.afterCR && (b == '\n' || b == 0) {
+			t.afterCR = false
+			return
+		}
+		t.afterCR = b == '\r'
+		t.pending = append(t.pending, b)
+
+	case telnetStateIAC:
+		switch b {
+		case telnetIAC:
+			// escaped 255 data byte
+			t.pending = append(t.pending, b)
+			t.state = telnetStateData
+		case telnetWILL, telnetWONT, telnetDO, telnetDONT:
+			t.verb = b
+			t.state = telnetStateOption
+		case telnetSB:
+			t.sub = t.sub[:0]
+			t.state = telnetStateSub
+		default:
+			// NOP, AYT, GA and friends carry nothing for us
+			t.state = telnetStateData
+		}
+
+	case telnetStateOption:
+		t.option(t.verb, b)
+		t.state = telnetStateData
+
+	case telnetStateSub:
+		if b == telnetIAC {
+			t.state = telnetStateSubIAC
+			return
+		}
+		if len(t.sub) < 256 {
+			t.sub = append(t.sub, b)
+		}
+
+	case telnetStateSubIAC:
+		switch b {
+		case telnetSE:
+			t.subnegotiation(t.sub)
+			t.state = telnetStateData
+		case telnetIAC:
+			t.sub = append(t.sub, b)
+			t.state = telnetStateSub
+		default:
+			t.state = telnetStateSub
+		}
+	}
+}
+
+// option answers the client's side of option negotiation. The options we
+// asked for are acknowledged silently, anything else is refused.
+func (t *telnetConn) option(command, option byte) {
+	switch command {
+	case telnetWILL:
+		switch option {
+		case telnetOptTType:
+			t.write([]byte{telnetIAC, telnetSB, telnetOptTType, telnetTTypeSend, telnetIAC, telnetSE})
+		case telnetOptNAWS, telnetOptSGA:
+		default:
+			t.command(telnetDONT, option)
+		}
+	case telnetDO:
+		switch option {
+		case telnetOptEcho, telnetOptSGA:
+		default:
+			t.command(telnetWONT, option)
+		}
+	}
+}
+
+func (t *telnetConn) subnegotiation(sub []byte) {
+	if len(sub) == 0 {
+		return
+	}
+
+	switch sub[0] {
+	case telnetOptNAWS:
+		if len(sub) < 5 {
+			return
+		}
+		width := int(binary.BigEndian.Uint16(sub[1:3]))
+		height := int(binary.BigEndian.Uint16(sub[3:5]))
+		if width == 0 || height == 0 {
+			return
+		}
+		width, height = clampSize(width, height)
+
+		t.mu.Lock()
+		t.width, t.height = width, height
+		resize := t.resize
+		t.mu.Unlock()
+		if resize != nil {
+			resize(tea.WindowSizeMsg{Width: width, Height: height})
+		}
+
+	case telnetOptTType:
+		if len(sub) < 2 || sub[1] != telnetTTypeIs {
+			return
+		}
+		t.mu.Lock()
+		// RFC 1091 names are upper case, TERM values are not
+		t.term = strings.ToLower(string(sub[2:]))
+		t.mu.Unlock()
+	}
+}
+
+func (t *telnetConn) command(command, option byte) {
+	t.write([]byte{telnetIAC, command, option})
+}
+
+// Write sends screen output, doubling IAC bytes
+func (t *telnetConn) Write(p []byte) (int, error) {
+	out := make([]byte, 0, len(p))
+	for _, b := range p {
+		if b == telnetIAC {
+			out = append(out, telnetIAC)
+		}
+		out = append(out, b)
+	}
+	if _, err := t.write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (t *telnetConn) write(p []byte) (int, error) {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	t.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	return t.conn.Write(p)
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+// recordingConn keeps what the server writes to a telnet client
+type recordingConn struct {
+	net.Conn
+	written bytes.Buffer
+}
+
+func (c *recordingConn) Write(p []byte) (int, error)      { return c.written.Write(p) }
+func (c *recordingConn) SetWriteDeadline(time.Time) error { return nil }
+
+func TestTelnetDecode(t *testing.T) {
+	const (
+		iac  = telnetIAC
+		sb   = telnetSB
+		se   = telnetSE
+		will = telnetWILL
+		do   = telnetDO
+	)
+	tests := []struct {
+		name   string
+		in     []byte
+		keys   string
+		width  int
+		height int
+		term   string
+		reply  []byte
+	}{
+		{"plain keys", []byte("jk"), "jk", 0, 0, "", nil},
+		{"enter as CR LF", []byte("a\r\nb"), "a\rb", 0, 0, "", nil},
+		{"enter as CR NUL", []byte("a\r\x00b"), "a\rb", 0, 0, "", nil},
+		{"escaped IAC", []byte{'a', iac, iac, 'b'}, "a\xffb", 0, 0, "", nil},
+		{"NOP dropped", []byte{'a', iac, 241, 'b'}, "ab", 0, 0, "", nil},
+		{"NAWS", []byte{iac, sb, telnetOptNAWS, 0, 120, 0, 40, iac, se, 'q'}, "q", 120, 40, "", nil},
+		{"NAWS with escaped 255", []byte{iac, sb, telnetOptNAWS, 0, iac, iac, 0, 50, iac, se}, "", 255, 50, "", nil},
+		{"NAWS clamped", []byte{iac, sb, telnetOptNAWS, 0x27, 0x10, 0x27, 0x10, iac, se}, "", maxTerminalWidth, maxTerminalHeight, "", nil},
+		{"NAWS zero size ignored", []byte{iac, sb, telnetOptNAWS, 0, 0, 0, 0, iac, se}, "", 0, 0, "", nil},
+		{"NAWS short ignored", []byte{iac, sb, telnetOptNAWS, 0, 80, iac, se}, "", 0, 0, "", nil},
+		{"terminal type", append(append([]byte{iac, sb, telnetOptTType, telnetTTypeIs}, "XTERM-256COLOR"...), iac, se), "", 0, 0, "xterm-256color", nil},
+		{"terminal type offered", []byte{iac, will, telnetOptTType}, "", 0, 0, "",
+			[]byte{iac, sb, telnetOptTType, telnetTTypeSend, iac, se}},
+		{"unknown option offered", []byte{iac, will, 42}, "", 0, 0, "", []byte{iac, telnetDONT, 42}},
+		{"unknown option asked", []byte{iac, do, 42}, "", 0, 0, "", []byte{iac, telnetWONT, 42}},
+		{"echo asked", []byte{iac, do, telnetOptEcho}, "", 0, 0, "", nil},
+		{"long subnegotiation capped", append(append([]byte{iac, sb, 99}, bytes.Repeat([]byte{1}, 1000)...), iac, se, 'x'), "x", 0, 0, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &recordingConn{}
+			tc := newTelnetConn(conn, func() {})
+			for _, b := range tt.in {
+				tc.decode(b)
+			}
+			if string(tc.pending) != tt.keys {
+				t.Errorf("keys = %q, want %q", tc.pending, tt.keys)
+			}
+			if tc.width != tt.width || tc.height != tt.height {
+				t.Errorf("size = %dx%d, want %dx%d", tc.width, tc.height, tt.width, tt.height)
+			}
+			if tc.term != tt.term {
+				t.Errorf("term = %q, want %q", tc.term, tt.term)
+			}
+			if !bytes.Equal(conn.written.Bytes(), tt.reply) {
+				t.Errorf("reply = %v, want %v", conn.written.Bytes(), tt.reply)
+			}
+		})
+	}
+}
+
+func TestTelnetWriteEscapesIAC(t *testing.T) {
+	conn := &recordingConn{}
+	tc := newTelnetConn(conn, func() {})
+	n, err := tc.Write([]byte{'a', telnetIAC, 'b'})
+	if err != nil || n != 3 {
+		t.Fatalf("Write = %d, %v, want 3, nil", n, err)
+	}
+	if want := []byte{'a', telnetIAC, telnetIAC, 'b'}; !bytes.Equal(conn.written.Bytes(), want) {
+		t.Errorf("wrote %v, want %v", conn.written.Bytes(), want)
+	}
+}
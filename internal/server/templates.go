@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+// visitorCountTTL is how long the visitor count shown by templates is
+// reused, so busy web pages do not each wait for the visitor store
+const visitorCountTTL = 10 * time.Second
+
+// renderLine returns a Line function for t that only gathers the template
+// data, reading the visitor store and the data file, once a line actually
+// contains a template expression
+func (srv *Server) renderLine(t *terminal) func(string) string {
+	var once sync.Once
+	var r *models.Renderer
+	return func(line string) string {
+		if !strings.Contains(line, "{{") {
+			return line
+		}
+		once.Do(func() { r = srv.renderer(t) })
+		return r.Line(line)
+	}
+}
+
+// renderer evaluates the template expressions in content lines for a
+// session, with t nil for content served without one, e.g. over the web
+func (srv *Server) renderer(t *terminal) *models.Renderer {
+	data := models.TemplateData{
+		Server: models.ServerInfo{
+			Uptime: roughDuration(time.Since(srv.started)),
+			Online: srv.sessions.count(),
+		},
+		Now:  time.Now(),
+		Data: srv.templateValues(),
+	}
+	data.Server.Visitors = srv.visitorCount()
+
+	if t != nil {
+		// the session is registered once its program runs, after rendering
+		data.Server.Online++
+
+		// the user name and TERM are chosen by the client, keep them from
+		// writing escape sequences into the TUI
+		data.Visitor = models.VisitorInfo{
+			User:      printable(t.user),
+			Transport: t.transport,
+			Term:      printable(t.term),
+			Width:     t.width,
+			Height:    t.height,
+		}
+		if loc := visitorLocation(t.tz); loc != nil {
+			data.Now = data.Now.In(loc)
+		}
+	}
+	data.Greeting = models.Greeting(data.Now)
+	return models.NewRenderer(data)
+}
+
+// visitorCount returns the number of returning visitors known, read from
+// the store at most once every visitorCountTTL
+func (srv *Server) visitorCount() int {
+	if srv.visitors == nil {
+		return 0
+	}
+	srv.visitorCountMu.Lock()
+	defer srv.visitorCountMu.Unlock()
+	if !srv.visitorCountAt.IsZero() && time.Since(srv.visitorCountAt) < visitorCountTTL {
+		return srv.visitorCountN
+	}
+	n, err := srv.visitors.Count()
+	if err != nil {
+		slog.Error("failed to count visitors", "error", err)
+		return srv.visitorCountN
+	}
+	srv.visitorCountN, srv.visitorCountAt = n, time.Now()
+	return n
+}
+
+// templateValues reads the template data file, so edits show in the next
+// session. Values that cannot be read render as missing.
+func (srv *Server) templateValues() map[string]any {
+	values := map[string]any{}
+	path := srv.config().Templates.DataFile
+	if path == "" {
+		return values
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &values)
+	}
+	if err != nil {
+		slog.Error("failed to read template data", "file", path, "error", err)
+	}
+	return values
+}
+
+// visitorLocation returns the time zone a client sent as TZ, nil when it
+// sent none or one this system does not know
+func visitorLocation(tz string) *time.Location {
+	tz = strings.TrimPrefix(tz, ":")
+	if tz == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// printable drops control characters from s
+func printable(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
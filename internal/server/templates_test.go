@@ -0,0 +1,87 @@
+package server
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/visitors"
+)
+
+func TestPrintable(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"alice", "alice"},
+		{"xterm-256color", "xterm-256color"},
+		{"\x1b[2Jroot", "[2Jroot"},
+		{"a\tb\nc\rd", "abcd"},
+		{"\u009b31m", "31m"},
+		{"zoë", "zoë"},
+	}
+	for _, tt := range tests {
+		if got := printable(tt.in); got != tt.want {
+			t.Errorf("printable(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// Template data is only gathered for content that uses templates, and only
+// once however many lines do
+func TestRenderLineGathersDataOnce(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+		reads int
+	}{
+		{"no templates", []string{"plain", "text"}, []string{"plain", "text"}, 0},
+		{"one template", []string{"plain", "{{.Data.name}}"}, []string{"plain", "?"}, 1},
+		{"several templates", []string{"{{.Data.a}}", "{{.Data.b}}", "{{.Server.Online}}"}, []string{"?", "?", "0"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig()
+			// a missing data file is logged every time it is read
+			config.Templates.DataFile = filepath.Join(t.TempDir(), "missing.json")
+			srv := newTestServer(t, config)
+			logs := captureLogs(t)
+
+			render := srv.renderLine(nil)
+			for i, line := range tt.lines {
+				if got := render(line); got != tt.want[i] {
+					t.Errorf("render(%q) = %q, want %q", line, got, tt.want[i])
+				}
+			}
+			if reads := strings.Count(logs.String(), "failed to read template data"); reads != tt.reads {
+				t.Errorf("data file read %d times, want %d", reads, tt.reads)
+			}
+		})
+	}
+}
+
+func TestVisitorCountCached(t *testing.T) {
+	srv := newTestServer(t, testConfig())
+	if n := srv.visitorCount(); n != 0 {
+		t.Errorf("count without a store = %d, want 0", n)
+	}
+
+	srv.visitors = visitors.NewStore(filepath.Join(t.TempDir(), "visitors.db"))
+	if err := srv.visitors.SaveVisit("SHA256:a", visitors.Visit{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.visitorCount(); n != 1 {
+		t.Fatalf("count = %d, want 1", n)
+	}
+	if err := srv.visitors.SaveVisit("SHA256:b", visitors.Visit{}); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.visitorCount(); n != 1 {
+		t.Errorf("count within the TTL = %d, want the cached 1", n)
+	}
+	srv.visitorCountAt = time.Now().Add(-visitorCountTTL)
+	if n := srv.visitorCount(); n != 2 {
+		t.Errorf("count after the TTL = %d, want 2", n)
+	}
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// environment telling a new process it was started by an upgrade
+const (
+	upgradeParentEnv  = "TUISERVER_UPGRADE_PARENT"   // PID of the old process
+	upgradeReadyFDEnv = "TUISERVER_UPGRADE_READY_FD" // pipe to report readiness on
+)
+
+// how long the new process has to start serving before the upgrade is
+// abandoned
+const upgradeTimeout = 30 * time.Second
+
+// upgrade starts a new process of the same executable with the listening
+// sockets passed like systemd socket activation does, and returns once it
+// reports that it serves. Under systemd the new process then becomes the
+// service's main process, so the service lives on when this one exits. On
+// error the new process is killed and this one carries on as before.
+func (srv *Server) upgrade() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("finding executable: %w", err)
+	}
+
+	var files []*os.File
+	var names []string
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for _, b := range srv.bound {
+		fl, ok := b.ln.(interface{ File() (*os.File, error) })
+		if !ok {
+			return fmt.Errorf("%s listener on %s cannot be passed on", b.service, b.ln.Addr())
+		}
+		// File returns a duplicate, closing it leaves the listener alone
+		f, err := fl.File()
+		if err != nil {
+			return fmt.Errorf("passing %s listener on %s: %w", b.service, b.ln.Addr(), err)
+		}
+		files = append(files, f)
+		names = append(names, b.service)
+	}
+
+	ready, readyWrite, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("creating readiness pipe: %w", err)
+	}
+	defer ready.Close()
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = append(files, readyWrite)
+	cmd.Env = append(upgradeEnviron(),
+		"LISTEN_FDS="+strconv.Itoa(len(files)),
+		"LISTEN_FDNAMES="+strings.Join(names, ":"),
+		upgradeParentEnv+"="+strconv.Itoa(os.Getpid()),
+		upgradeReadyFDEnv+"="+strconv.Itoa(listenFDsStart+len(files)),
+	)
+
+	err = cmd.Start()
+	readyWrite.Close()
+	if err != nil {
+		return fmt.Errorf("starting %s: %w", exe, err)
+	}
+	slog.Info("new process started", "pid", cmd.Process.Pid, "sockets", len(files))
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	// the pipe reports "ready" once the new process serves, or EOF when it
+	// exits without getting there
+	reported := make(chan string, 1)
+	go func() {
+		msg, _ := io.ReadAll(ready)
+		reported <- strings.TrimSpace(string(msg))
+	}()
+
+	select {
+	case msg := <-reported:
+		if msg == "ready" {
+			// sent by this process, which systemd accepts from the main
+			// process with the default NotifyAccess=main
+			notifyServiceManager("MAINPID=" + strconv.Itoa(cmd.Process.Pid))
+			go func() {
+				if err := <-exited; err != nil {
+					slog.Warn("new process exited", "pid", cmd.Process.Pid, "error", err)
+				}
+			}()
+			return nil
+		}
+		err = errors.New("new process exited before serving")
+	case <-time.After(upgradeTimeout):
+		err = fmt.Errorf("new process not serving after %s", upgradeTimeout)
+	}
+	cmd.Process.Kill()
+	if exitErr := <-exited; exitErr != nil {
+		err = fmt.Errorf("%w: %v", err, exitErr)
+	}
+	return err
+}
+
+// upgradeEnviron is this process's environment without the variables
+// describing its own sockets
+func upgradeEnviron() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		switch name {
+		case "LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES", upgradeParentEnv, upgradeReadyFDEnv:
+			continue
+		}
+		env = append(env, kv)
+	}
+	return env
+}
+
+// notifyUpgradeParent tells the process that started this one by an
+// upgrade that the listeners are being served, so it can stop accepting.
+// It reports whether there was such a process.
+func notifyUpgradeParent() bool {
+	value := os.Getenv(upgradeReadyFDEnv)
+	if value == "" {
+		return false
+	}
+	os.Unsetenv(upgradeReadyFDEnv)
+
+	fd, err := strconv.Atoi(value)
+	if err != nil {
+		slog.Warn("invalid upgrade readiness descriptor", "value", value)
+		return true
+	}
+	f := os.NewFile(uintptr(fd), "upgrade-ready")
+	defer f.Close()
+	if _, err := f.Write([]byte("ready\n")); err != nil {
+		slog.Warn("failed to notify the old process", "error", err)
+	}
+	return true
+}
+
+// isUpgradeSignal reports whether sig asks for an upgrade
+func isUpgradeSignal(sig os.Signal) bool {
+	for _, upgrade := range upgradeSignals {
+		if sig == upgrade {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package server
+
+import "os"
+
+// upgrades need descriptor passing, which only unix systems have
+var upgradeSignals []os.Signal
@@ -0,0 +1,11 @@
+//go:build unix
+
+package server
+
+import (
+	"os"
+	"syscall"
+)
+
+// signals that hand the listeners to a new process
+var upgradeSignals = []os.Signal{syscall.SIGUSR2}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/tui"
+	"github.com/cankurttekin/sh.kurttekin.com/internal/visitors"
+)
+
+// addRevision records content in the visitor store and returns its
+// revision number, 0 when it could not be recorded
+func (srv *Server) addRevision(p models.Portfolio) uint64 {
+	if srv.visitors == nil {
+		return 0
+	}
+	n, err := srv.visitors.AddRevision(p)
+	if err != nil {
+		slog.Error("failed to record content revision", "error", err)
+		return 0
+	}
+	return n
+}
+
+// welcomeBack greets a visitor whose key was seen before: no welcome
+// screen, the section they last read, and what changed in the content
+// since, as far as view lets them see it. Changes are found before
+// templates are rendered, so values that differ per session are no news.
+// It returns the visit to update when the session ends, false when the
+// visitor is not tracked.
+func (srv *Server) welcomeBack(m *tui.Model, t terminal, revision uint64, current models.Portfolio, view func(models.Portfolio) models.Portfolio, render func(string) string) (visitors.Visit, bool) {
+	if srv.visitors == nil || t.fingerprint == "" {
+		return visitors.Visit{}, false
+	}
+	visit, found, err := srv.visitors.Visit(t.fingerprint)
+	if err != nil {
+		t.logger.Error("failed to look up visitor", "error", err)
+		return visit, false
+	}
+	if !found {
+		return visit, true
+	}
+
+	m.ShowWelcome = false
+	m.OpenSection(visit.LastSection)
+	m.StatusMessage = fmt.Sprintf("Welcome back! Last visit %s ago", roughDuration(time.Since(visit.Last)))
+
+	if visit.Revision != 0 && revision != 0 && visit.Revision != revision {
+		old, ok, err := srv.visitors.Revision(visit.Revision)
+		if err != nil {
+			t.logger.Error("failed to read content revision", "revision", visit.Revision, "error", err)
+		} else if ok {
+			m.WhatsNew = current.Diff(view(old.Content))
+			for i, change := range m.WhatsNew {
+				added := make([]string, len(change.Added))
+				for j, line := range change.Added {
+					added[j] = render(line)
+				}
+				m.WhatsNew[i].Added = added
+			}
+		}
+	}
+
+	t.logger.Info("returning visitor", "visits", visit.Count, "last_visit", visit.Last.Format(time.RFC3339), "changes", len(m.WhatsNew))
+	return visit, true
+}
+
+// rememberVisit records a finished session of a tracked visitor
+func (srv *Server) rememberVisit(t terminal, visit visitors.Visit, start time.Time, revision uint64, section string) {
+	if visit.First.IsZero() {
+		visit.First = start
+	}
+	visit.Last = start
+	visit.Count++
+	if revision != 0 {
+		visit.Revision = revision
+	}
+	if section != "" {
+		visit.LastSection = section
+	}
+	if err := srv.visitors.SaveVisit(t.fingerprint, visit); err != nil {
+		t.logger.Error("failed to save visit", "error", err)
+	}
+}
+
+// roughDuration describes d in the largest whole unit
+func roughDuration(d time.Duration) string {
+	switch {
+	case d < 2*time.Minute:
+		return "a minute"
+	case d < 2*time.Hour:
+		return fmt.Sprintf("%d minutes", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%d hours", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%d days", int(d.Hours()/24))
+	}
+}
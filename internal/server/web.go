@@ -0,0 +1,100 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/render"
+)
+
+// terminal HTTP clients that get ANSI colored text instead of HTML
+var terminalAgents = []string{"curl/", "wget/", "httpie/", "xh/", "fetch"}
+
+// handlePortfolio serves the portfolio in the format the client asks for:
+// plain text for ?plain, JSON for Accept: application/json, ANSI text for
+// curl and friends, HTML otherwise
+func (srv *Server) handlePortfolio(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Vary", "Accept, User-Agent")
+	format := requestFormat(r)
+	portfolio := srv.publicContent()
+
+	slog.Debug("HTTP portfolio request", "ip", remoteIP(httpRemoteAddr(r)), "format", format,
+		"user_agent", r.UserAgent())
+
+	switch format {
+	case "plain":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, render.Plain(portfolio))
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(portfolio)
+	case "ansi":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, render.ANSI(portfolio))
+	default:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := render.HTML(w, portfolio, srv.sshCommand(r.Host)); err != nil {
+			slog.Error("failed to render HTML", "error", err)
+		}
+	}
+}
+
+func requestFormat(r *http.Request) string {
+	if r.URL.Query().Has("plain") {
+		return "plain"
+	}
+	if strings.Contains(r.Header.Get("Accept"), "application/json") {
+		return "json"
+	}
+	agent := strings.ToLower(r.UserAgent())
+	for _, prefix := range terminalAgents {
+		if strings.HasPrefix(agent, prefix) {
+			return "ansi"
+		}
+	}
+	return "html"
+}
+
+// sshCommand suggests how to reach the TUI from the HTTP host name
+func (srv *Server) sshCommand(httpHost string) string {
+	host, _, err := net.SplitHostPort(httpHost)
+	if err != nil {
+		host = httpHost
+	}
+	if host == "" || len(srv.config().ListenAddrs) == 0 {
+		return ""
+	}
+	_, port, err := net.SplitHostPort(srv.config().ListenAddrs[0])
+	if err != nil {
+		return ""
+	}
+	if port == "22" {
+		return "ssh " + host
+	}
+	return fmt.Sprintf("ssh -p %s %s", port, host)
+}
+
+// httpRemoteAddr returns the client address of a request as a net.Addr
+func httpRemoteAddr(r *http.Request) net.Addr {
+	addr, err := net.ResolveTCPAddr("tcp", r.RemoteAddr)
+	if err != nil {
+		return &net.TCPAddr{}
+	}
+	return addr
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+func TestRequestFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		accept string
+		agent  string
+		want   string
+	}{
+		{"browser", "/", "text/html,application/xhtml+xml", "Mozilla/5.0", "html"},
+		{"no headers", "/", "", "", "html"},
+		{"plain", "/?plain", "", "Mozilla/5.0", "plain"},
+		{"plain wins over json", "/?plain", "application/json", "", "plain"},
+		{"plain wins over curl", "/?plain", "", "curl/8.4.0", "plain"},
+		{"json", "/", "application/json", "Mozilla/5.0", "json"},
+		{"json among others", "/", "text/html;q=0.5, application/json", "", "json"},
+		{"json wins over curl", "/", "application/json", "curl/8.4.0", "json"},
+		{"curl", "/", "*/*", "curl/8.4.0", "ansi"},
+		{"wget", "/", "*/*", "Wget/1.21.4", "ansi"},
+		{"httpie", "/", "*/*", "HTTPie/3.2.2", "ansi"},
+		{"curl later in the agent", "/", "", "Mozilla/5.0 curl/8.4.0", "html"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			r.Header.Set("User-Agent", tt.agent)
+			if got := requestFormat(r); got != tt.want {
+				t.Errorf("requestFormat = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlePortfolio(t *testing.T) {
+	srv := newTestServer(t, testConfig())
+	srv.portfolio = models.Portfolio{
+		Title:    "Jane <Doe>",
+		Sections: []models.Section{{Title: "About", Content: []string{"Hi https://example.com"}}},
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		url         string
+		accept      string
+		agent       string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"html", http.MethodGet, "/", "", "Mozilla/5.0", http.StatusOK, "text/html; charset=utf-8", "Jane &lt;Doe&gt;"},
+		{"plain", http.MethodGet, "/?plain", "", "", http.StatusOK, "text/plain; charset=utf-8", "Jane <Doe>\n=========="},
+		{"json", http.MethodGet, "/", "application/json", "", http.StatusOK, "application/json", `"title": "Jane \u003cDoe\u003e"`},
+		{"ansi", http.MethodGet, "/", "", "curl/8.4.0", http.StatusOK, "text/plain; charset=utf-8", "\x1b["},
+		{"head", http.MethodHead, "/", "", "", http.StatusOK, "text/html; charset=utf-8", ""},
+		{"other path", http.MethodGet, "/admin", "", "", http.StatusNotFound, "", ""},
+		{"post", http.MethodPost, "/", "", "", http.StatusMethodNotAllowed, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.url, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			r.Header.Set("User-Agent", tt.agent)
+			w := httptest.NewRecorder()
+			srv.handlePortfolio(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			if got := w.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept, User-Agent" {
+				t.Errorf("Vary = %q", got)
+			}
+			if !strings.Contains(w.Body.String(), tt.body) {
+				t.Errorf("body lacks %q\n%s", tt.body, w.Body)
+			}
+			if tt.name == "json" {
+				var p models.Portfolio
+				if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil || p.Title != "Jane <Doe>" {
+					t.Errorf("JSON body decodes to %+v, %v", p, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSSHCommand(t *testing.T) {
+	tests := []struct {
+		listen []string
+		host   string
+		want   string
+	}{
+		{[]string{":2222"}, "example.com", "ssh -p 2222 example.com"},
+		{[]string{":22"}, "example.com:8080", "ssh example.com"},
+		{[]string{"[::]:2222"}, "[2001:db8::1]:80", "ssh -p 2222 2001:db8::1"},
+		{[]string{":2222"}, "", ""},
+		{nil, "example.com", ""},
+	}
+	for _, tt := range tests {
+		config := testConfig()
+		config.ListenAddrs = tt.listen
+		srv := newTestServer(t, config)
+		if got := srv.sshCommand(tt.host); got != tt.want {
+			t.Errorf("sshCommand(%q) with %v = %q, want %q", tt.host, tt.listen, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path"
+	"slices"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/gorilla/websocket"
+)
+
+// browser sessions have no SSH user or TERM of their own
+const (
+	webUser = "web"
+	webTerm = "xterm-256color" // what xterm.js emulates
+)
+
+// how long a browser has to report its terminal size after connecting
+const webHandshakeTimeout = 10 * time.Second
+
+//go:embed terminal.html
+var terminalPageSource string
+
+var terminalPage = template.Must(template.New("terminal").Parse(terminalPageSource))
+
+//go:generate go run gen_xterm.go
+
+// xterm.js, vendored by go generate so browsers only load scripts from
+// this server
+//
+//go:embed xterm
+var xtermFiles embed.FS
+
+// files the terminal page loads from /terminal/assets/
+var xtermAssets = []string{"xterm.css", "xterm.js", "xterm-addon-fit.js"}
+
+// hasXtermAssets reports whether xterm.js was built in
+func hasXtermAssets() bool {
+	for _, name := range xtermAssets {
+		if _, err := fs.Stat(xtermFiles, path.Join("xterm", name)); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// handleTerminalAssets serves the built in xterm.js files
+func (srv *Server) handleTerminalAssets(w http.ResponseWriter, r *http.Request) {
+	name := path.Base(r.URL.Path)
+	if !slices.Contains(xtermAssets, name) {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := xtermFiles.ReadFile(path.Join("xterm", name))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	switch path.Ext(name) {
+	case ".css":
+		w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	default:
+		w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	http.ServeContent(w, r, name, srv.started, bytes.NewReader(data))
+}
+
+// the default origin check only lets the page served by this host connect
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// handleTerminalPage serves the xterm.js page that connects to /terminal/ws
+func (srv *Server) handleTerminalPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := terminalPage.Execute(w, srv.publicContent()); err != nil {
+		slog.Error("failed to render terminal page", "error", err)
+	}
+}
+
+// handleTerminalSocket runs the TUI over a WebSocket. Binary frames carry
+// keystrokes and screen output, text frames carry JSON control messages
+// from the page; the first one must be a resize with the terminal size.
+func (srv *Server) handleTerminalSocket(w http.ResponseWriter, r *http.Request) {
+	ip := remoteIP(httpRemoteAddr(r))
+	if ok, reason := srv.access.check(ip); !ok {
+		slog.Info("connection refused", "reason", reason, "ip", ip, "transport", "web")
+		rejectionsTotal.WithLabelValues(reason).Inc()
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	if srv.draining.Load() || !srv.startStream() {
+		http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	defer srv.streamSessions.Done()
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader has already replied with an error
+		slog.Debug("websocket upgrade failed", "ip", ip, "error", err)
+		return
+	}
+	defer conn.Close()
+
+	startTime := time.Now()
+	id := newSessionID()
+
+	// every line logged for this session carries the same attributes
+	logger := slog.With(
+		"session", id,
+		"user", webUser,
+		"ip", ip,
+		"transport", "web",
+	)
+	logger.Info("connection opened")
+
+	ctx, cancel := context.WithCancel(srv.hangup)
+	defer cancel()
+	ws := newWSTerminal(conn, cancel)
+	defer ws.close()
+
+	release, reason := srv.limiter.acquire(ip)
+	if release == nil {
+		ws.Write([]byte(rejectMessages[reason] + "\r\n"))
+		logger.Warn("connection rejected", "reason", reason)
+		rejectionsTotal.WithLabelValues(reason).Inc()
+		return
+	}
+	defer release()
+
+	size, err := ws.handshake(webHandshakeTimeout)
+	if err != nil {
+		srv.access.strike(ip, "no terminal size")
+		rejectionsTotal.WithLabelValues("no_pty").Inc()
+		logger.Info("connection closed", "reason", "no terminal size", "error", err, "duration", time.Since(startTime))
+		return
+	}
+	go ws.readLoop(ctx)
+
+	srv.runTUI(terminal{
+		ctx:       ctx,
+		id:        id,
+		transport: "web",
+		user:      webUser,
+		ip:        ip,
+		term:      webTerm,
+		width:     size.Width,
+		height:    size.Height,
+		rw:        ws,
+		resize:    ws.resize,
+		logger:    logger,
+	}, startTime)
+}
+
+// newSessionID returns a random hex ID for sessions that do not get one
+// from the SSH handshake
+func newSessionID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// controlMessage is a text frame sent by the terminal page
+type controlMessage struct {
+	Type string `json:"type"`
+	Cols int    `json:"cols"`
+	Rows int    `json:"rows"`
+}
+
+// wsTerminal adapts a WebSocket to the io.ReadWriter the TUI runs on
+type wsTerminal struct {
+	conn   *websocket.Conn
+	cancel context.CancelFunc // called when the browser goes away
+
+	in     *io.PipeReader // keystrokes read by the TUI
+	keys   *io.PipeWriter // keystrokes received from the browser
+	resize chan tea.WindowSizeMsg
+
+	writeMu sync.Mutex
+}
+
+func newWSTerminal(conn *websocket.Conn, cancel context.CancelFunc) *wsTerminal {
+	conn.SetReadLimit(64 * 1024)
+	pr, pw := io.Pipe()
+	return &wsTerminal{
+		conn:   conn,
+		cancel: cancel,
+		in:     pr,
+		keys:   pw,
+		resize: make(chan tea.WindowSizeMsg),
+	}
+}
+
+// handshake waits for the first resize message
+func (t *wsTerminal) handshake(timeout time.Duration) (tea.WindowSizeMsg, error) {
+	t.conn.SetReadDeadline(time.Now().Add(timeout))
+	defer t.conn.SetReadDeadline(time.Time{})
+
+	for {
+		kind, data, err := t.conn.ReadMessage()
+		if err != nil {
+			return tea.WindowSizeMsg{}, err
+		}
+		if kind != websocket.TextMessage {
+			continue
+		}
+		if size, ok := parseResize(data); ok {
+			return size, nil
+		}
+	}
+}
+
+// readLoop feeds keystrokes to the TUI input and resizes to the resize
+// channel until the browser disconnects
+func (t *wsTerminal) readLoop(ctx context.Context) {
+	defer t.cancel()
+	defer t.keys.Close()
+
+	for {
+		kind, data, err := t.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		switch kind {
+		case websocket.BinaryMessage:
+			if _, err := t.keys.Write(data); err != nil {
+				// the TUI has finished
+				return
+			}
+		case websocket.TextMessage:
+			size, ok := parseResize(data)
+			if !ok {
+				continue
+			}
+			select {
+			case t.resize <- size:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func parseResize(data []byte) (tea.WindowSizeMsg, bool) {
+	var msg controlMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.Type != "resize" {
+		return tea.WindowSizeMsg{}, false
+	}
+	if msg.Cols <= 0 || msg.Rows <= 0 {
+		return tea.WindowSizeMsg{}, false
+	}
+	width, height := clampSize(msg.Cols, msg.Rows)
+	return tea.WindowSizeMsg{Width: width, Height: height}, true
+}
+
+// Read returns keystrokes sent by the browser
+func (t *wsTerminal) Read(p []byte) (int, error) {
+	return t.in.Read(p)
+}
+
+// Write sends screen output to the browser as one binary frame
+func (t *wsTerminal) Write(p []byte) (int, error) {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+
+	t.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if err := t.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// close stops the input pipe and says goodbye to the browser
+func (t *wsTerminal) close() {
+	t.in.Close()
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	err := t.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	if err != nil && !errors.Is(err, websocket.ErrCloseSent) {
+		slog.Debug("websocket close failed", "error", err)
+	}
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+)
+
+// every script and stylesheet must come from this server
+func TestTerminalPageLoadsNothingExternal(t *testing.T) {
+	external := regexp.MustCompile(`(?i)(src|href)="(https?:)?//`)
+	if m := external.FindString(terminalPageSource); m != "" {
+		t.Errorf("terminal page loads %s...", m)
+	}
+}
+
+func TestTerminalAssets(t *testing.T) {
+	srv := newTestServer(t, testConfig())
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/terminal/assets/README.md", http.StatusNotFound},
+		{"/terminal/assets/../gen_xterm.go", http.StatusNotFound},
+		{"/terminal/assets/", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		srv.handleTerminalAssets(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("GET %s = %d, want %d", tt.path, rec.Code, tt.want)
+		}
+	}
+
+}
+
+// The web terminal is on by default and needs xterm.js vendored, a build
+// without it silently serves no browser TUI
+func TestXtermVendored(t *testing.T) {
+	for _, name := range append([]string{"xterm.LICENSE"}, xtermAssets...) {
+		if data, err := xtermFiles.ReadFile("xterm/" + name); err != nil || len(data) == 0 {
+			t.Errorf("xterm/%s is missing, run go generate ./internal/server and commit what it writes", name)
+		}
+	}
+	if t.Failed() {
+		return
+	}
+
+	srv := newTestServer(t, testConfig())
+	for _, name := range xtermAssets {
+		rec := httptest.NewRecorder()
+		srv.handleTerminalAssets(rec, httptest.NewRequest(http.MethodGet, "/terminal/assets/"+name, nil))
+		if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Errorf("GET %s = %d with %d bytes", name, rec.Code, rec.Body.Len())
+		}
+	}
+}
+
+func TestParseResize(t *testing.T) {
+	tests := []struct {
+		data          string
+		ok            bool
+		width, height int
+	}{
+		{`{"type":"resize","cols":120,"rows":40}`, true, 120, 40},
+		{`{"type":"resize","cols":100000,"rows":100000}`, true, maxTerminalWidth, maxTerminalHeight},
+		{`{"type":"resize","cols":0,"rows":40}`, false, 0, 0},
+		{`{"type":"resize","cols":-5,"rows":40}`, false, 0, 0},
+		{`{"type":"input","cols":80,"rows":24}`, false, 0, 0},
+		{`{"type":"resize","cols":"80"}`, false, 0, 0},
+		{`not json`, false, 0, 0},
+	}
+	for _, tt := range tests {
+		size, ok := parseResize([]byte(tt.data))
+		if ok != tt.ok || size.Width != tt.width || size.Height != tt.height {
+			t.Errorf("parseResize(%s) = %dx%d %v, want %dx%d %v", tt.data, size.Width, size.Height, ok, tt.width, tt.height, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+// adminState is the admin mode of an owner session. Edits are made on the
+// session's own copy of the portfolio and only reach other visitors once
+// saved through OnSave.
+type adminState struct {
+	active    bool
+	row       int  // selected row of the current section, 0 is the title
+	editing   bool // the selected row is being edited in place
+	editor    lineEditor
+	inserted  bool // the edited line was just added, canceling removes it
+	theme     int  // index in models.ThemePresets, -1 for a custom theme
+	changes   int  // edits since the last save
+	saving    bool
+	quitArmed bool // q was pressed once with unsaved changes
+}
+
+// message when OnSave has finished
+type contentSavedMsg struct {
+	err error
+}
+
+// enterAdmin switches an owner session to admin mode
+func (m *Model) enterAdmin() {
+	if !m.admin.active && m.admin.changes == 0 {
+		// the portfolio may be shared with other sessions, edit a copy
+		m.Portfolio = m.Portfolio.Clone()
+		m.admin.theme = themePreset(m.Portfolio.Theme)
+	}
+	m.admin.active = true
+	m.admin.row = 0
+	m.InLinkMode = false
+	m.StatusMode = "ADMIN"
+	m.StatusMessage = "Editing content, changes are private until saved"
+}
+
+// themePreset returns the index of the preset matching theme, or -1
+func themePreset(theme models.Theme) int {
+	for i, preset := range models.ThemePresets {
+		if preset.Theme == theme {
+			return i
+		}
+	}
+	return -1
+}
+
+// updateAdmin handles keys in admin mode
+func (m Model) updateAdmin(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if key == "ctrl+c" {
+		m.ExitReason = ExitQuit
+		m.emitLeave()
+		return m, tea.Quit
+	}
+	if m.admin.editing {
+		m.updateEditor(msg)
+		return m, nil
+	}
+
+	if key != "q" {
+		m.admin.quitArmed = false
+	}
+	sec := &m.Portfolio.Sections[m.SectionCursor]
+
+	switch key {
+	case "q":
+		if m.admin.changes > 0 && !m.admin.quitArmed {
+			m.admin.quitArmed = true
+			m.StatusMessage = fmt.Sprintf("%d unsaved changes, s: save, q: quit anyway", m.admin.changes)
+			return m, nil
+		}
+		m.ExitReason = ExitQuit
+		m.emitLeave()
+		return m, tea.Quit
+	case "esc", "a":
+		m.admin.active = false
+		m.StatusMode = "NORMAL"
+		m.StatusMessage = "Ready"
+		if m.admin.changes > 0 {
+			m.StatusMessage = fmt.Sprintf("%d unsaved changes, a: back to admin mode", m.admin.changes)
+		}
+	case "left", "h":
+		if m.SectionCursor > 0 {
+			m.showAdminSection(m.SectionCursor - 1)
+		}
+	case "right", "l":
+		if m.SectionCursor < len(m.Portfolio.Sections)-1 {
+			m.showAdminSection(m.SectionCursor + 1)
+		}
+	case "up", "k":
+		if m.admin.row > 0 {
+			m.admin.row--
+		}
+	case "down", "j":
+		if m.admin.row < len(sec.Content) {
+			m.admin.row++
+		}
+	case "enter", "e":
+		text := sec.Title
+		if m.admin.row > 0 {
+			text = sec.Content[m.admin.row-1]
+		}
+		m.startEditing(text, false)
+	case "o":
+		// add a line below the selected row
+		at := m.admin.row
+		sec.Content = append(sec.Content[:at], append([]string{""}, sec.Content[at:]...)...)
+		m.admin.row = at + 1
+		m.startEditing("", true)
+	case "d":
+		if m.admin.row == 0 {
+			m.StatusMessage = "Titles cannot be deleted, hide the section with x"
+			break
+		}
+		at := m.admin.row - 1
+		sec.Content = append(sec.Content[:at], sec.Content[at+1:]...)
+		if m.admin.row > len(sec.Content) {
+			m.admin.row = len(sec.Content)
+		}
+		m.Links = FindLinks(sec.Content)
+		m.changed("Line deleted")
+	case "[", "]":
+		to := m.SectionCursor - 1
+		if key == "]" {
+			to = m.SectionCursor + 1
+		}
+		if to < 0 || to >= len(m.Portfolio.Sections) {
+			break
+		}
+		sections := m.Portfolio.Sections
+		sections[m.SectionCursor], sections[to] = sections[to], sections[m.SectionCursor]
+		m.SectionCursor = to
+		m.refreshTabs()
+		m.changed("Section moved")
+	case "x":
+		if !sec.Hidden && !sec.Private && m.visibleSections() == 1 {
+			m.StatusMessage = "The last visible section cannot be hidden"
+			break
+		}
+		sec.Hidden = !sec.Hidden
+		m.refreshTabs()
+		if sec.Hidden {
+			m.changed("Section hidden from visitors")
+		} else {
+			m.changed("Section shown to visitors")
+		}
+	case "p":
+		if !sec.Hidden && !sec.Private && m.visibleSections() == 1 {
+			m.StatusMessage = "The last visible section cannot be made private"
+			break
+		}
+		sec.Private = !sec.Private
+		if sec.Private {
+			m.changed("Section private, shown to allowed keys and invites only")
+		} else {
+			m.changed("Section shown to every visitor")
+		}
+	case "t":
+		m.admin.theme = (m.admin.theme + 1) % len(models.ThemePresets)
+		preset := models.ThemePresets[m.admin.theme]
+		m.Portfolio.Theme = preset.Theme
+		m.changed(fmt.Sprintf("Theme: %s, applies to everyone once saved", preset.Name))
+	case "s":
+		return m.save()
+	}
+	return m, nil
+}
+
+// showAdminSection switches sections without leaving admin mode
+func (m *Model) showAdminSection(i int) {
+	m.showSection(i)
+	m.admin.row = 0
+	m.StatusMode = "ADMIN"
+}
+
+// visibleSections counts the sections every visitor sees
+func (m Model) visibleSections() int {
+	visible := 0
+	for _, sec := range m.Portfolio.Sections {
+		if !sec.Hidden && !sec.Private {
+			visible++
+		}
+	}
+	return visible
+}
+
+// changed counts an edit and reports it in the status bar
+func (m *Model) changed(message string) {
+	m.admin.changes++
+	m.StatusMessage = fmt.Sprintf("%s (%d unsaved)", message, m.admin.changes)
+}
+
+func (m *Model) startEditing(text string, inserted bool) {
+	m.admin.editing = true
+	m.admin.inserted = inserted
+	m.admin.editor = newLineEditor(text)
+	m.StatusMode = "EDIT"
+	m.StatusMessage = "enter: keep • esc: cancel"
+}
+
+// updateEditor handles keys while a title or line is edited in place
+func (m *Model) updateEditor(msg tea.KeyMsg) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.finishEditing(true)
+	case tea.KeyEsc:
+		m.finishEditing(false)
+	default:
+		m.admin.editor.update(msg)
+	}
+}
+
+// finishEditing stores the edited text, or drops it when keep is false
+func (m *Model) finishEditing(keep bool) {
+	sec := &m.Portfolio.Sections[m.SectionCursor]
+	text := m.admin.editor.String()
+	m.StatusMode = "ADMIN"
+
+	switch {
+	case !keep:
+		if m.admin.inserted {
+			sec.Content = append(sec.Content[:m.admin.row-1], sec.Content[m.admin.row:]...)
+			m.admin.row--
+		}
+		m.StatusMessage = "Edit canceled"
+	case m.admin.row == 0:
+		if strings.TrimSpace(text) == "" {
+			m.StatusMode = "EDIT"
+			m.StatusMessage = "Titles cannot be empty"
+			return
+		}
+		sec.Title = text
+		m.refreshTabs()
+		m.changed("Title changed")
+	default:
+		sec.Content[m.admin.row-1] = text
+		m.Links = FindLinks(sec.Content)
+		m.changed("Line changed")
+	}
+	m.admin.editing = false
+}
+
+// save hands a copy of the edited portfolio to OnSave
+func (m Model) save() (tea.Model, tea.Cmd) {
+	if m.OnSave == nil {
+		m.StatusMessage = "Saving is not available"
+		return m, nil
+	}
+	if m.admin.saving {
+		return m, nil
+	}
+	if err := m.Portfolio.Validate(); err != nil {
+		m.StatusMessage = fmt.Sprintf("Cannot save: %v", err)
+		return m, nil
+	}
+
+	m.admin.saving = true
+	m.StatusMessage = "Saving..."
+	save, portfolio := m.OnSave, m.Portfolio.Clone()
+	return m, func() tea.Msg {
+		return contentSavedMsg{err: save(portfolio)}
+	}
+}
+
+func (m Model) handleContentSaved(msg contentSavedMsg) Model {
+	m.admin.saving = false
+	if msg.err != nil {
+		m.StatusMessage = fmt.Sprintf("Save failed: %v", msg.err)
+		return m
+	}
+	m.admin.changes = 0
+	m.StatusMessage = "Saved, new visitors get this version"
+	return m
+}
+
+// renderAdminSection renders the current section with the selected row
+// marked and the row being edited shown with a cursor
+func (m Model) renderAdminSection(st *Styles, width int) string {
+	sec := m.Portfolio.Sections[m.SectionCursor]
+	marker := func(row int) string {
+		if row == m.admin.row {
+			return st.Focused.Render("▸ ")
+		}
+		return "  "
+	}
+
+	var b strings.Builder
+	title := st.SectionHeader.Render("✦" + strings.ToUpper(sec.Title) + "✦")
+	if m.admin.editing && m.admin.row == 0 {
+		title = m.admin.editor.View()
+	}
+	b.WriteString(marker(0) + title)
+	if sec.Hidden {
+		b.WriteString(st.Inactive.Render("  hidden from visitors"))
+	} else if sec.Private {
+		b.WriteString(st.Inactive.Render("  private"))
+	}
+	b.WriteString("\n")
+	b.WriteString(st.SectionDivider.Render(strings.Repeat("─", width/2)) + "\n\n")
+
+	for i, line := range sec.Content {
+		row := i + 1
+		if m.admin.editing && m.admin.row == row {
+			line = m.admin.editor.View()
+		} else {
+			line = strings.ReplaceAll(line, "\n", "↵")
+		}
+		b.WriteString(marker(row) + line + "\n")
+	}
+	if len(sec.Content) == 0 {
+		b.WriteString(st.Inactive.Render("  no lines, o: add one") + "\n")
+	}
+	return b.String()
+}
+
+func (m Model) adminHelp() string {
+	if m.admin.editing {
+		return "type to edit • ←/→: move • enter: keep • esc: cancel"
+	}
+	return "←/→: section • ↑/↓: line • e: edit • o: add • d: delete • [/]: move • x: hide • p: private • t: theme • s: save • esc: exit"
+}
@@ -0,0 +1,337 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ConsoleSession is a live session listed in the admin console
+type ConsoleSession struct {
+	ID        string
+	User      string
+	IP        string
+	Transport string
+	Term      string
+	Width     int
+	Height    int
+	Section   string
+	Started   time.Time
+}
+
+// ConsoleActions connect the console to the server's live sessions
+type ConsoleActions struct {
+	List    func() []ConsoleSession
+	Kick    func(id string) error
+	Ban     func(ip string) error
+	Message func(id, text string) error
+
+	Broadcast   func(text string) error // announcement to every session
+	Maintenance func(text string) error // banner for all sessions, "" clears it
+}
+
+// ConsoleModel is the owner's view of who is connected right now
+type ConsoleModel struct {
+	Width  int
+	Height int
+
+	actions   ConsoleActions
+	sessions  []ConsoleSession
+	selected  string // ID of the selected session, kept across refreshes
+	confirm   string // "kick" or "ban" waiting for y
+	composing string // "message", "broadcast" or "maintenance" being typed
+	target    string // ID of the session a kick, ban or message is for
+	editor    lineEditor
+	status    string
+}
+
+// message to reload the session list
+type consoleTickMsg struct{}
+
+// message when an action on a session has finished
+type consoleDoneMsg struct {
+	status string
+}
+
+// NewConsoleModel returns a console listing the sessions from actions.List
+func NewConsoleModel(actions ConsoleActions, width, height int) ConsoleModel {
+	m := ConsoleModel{Width: width, Height: height, actions: actions, status: "Ready"}
+	m.refresh()
+	return m
+}
+
+func consoleTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return consoleTickMsg{}
+	})
+}
+
+func (m ConsoleModel) Init() tea.Cmd {
+	return tea.Batch(tea.ClearScreen, consoleTick())
+}
+
+// refresh reloads the sessions, keeping the selection on the same session
+func (m *ConsoleModel) refresh() {
+	m.sessions = m.actions.List()
+	if m.cursor() < 0 && len(m.sessions) > 0 {
+		m.selected = m.sessions[0].ID
+	}
+}
+
+// cursor returns the index of the selected session, -1 when it is gone
+func (m ConsoleModel) cursor() int {
+	for i, s := range m.sessions {
+		if s.ID == m.selected {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m ConsoleModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case consoleTickMsg:
+		m.refresh()
+		return m, consoleTick()
+	case consoleDoneMsg:
+		m.status = msg.status
+		m.refresh()
+		return m, nil
+	case tea.WindowSizeMsg:
+		m.Width = msg.Width
+		m.Height = msg.Height
+		return m, nil
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m ConsoleModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+
+	i := m.cursor()
+
+	if m.composing != "" {
+		switch msg.Type {
+		case tea.KeyEnter:
+			return m.send()
+		case tea.KeyEsc:
+			m.composing = ""
+			m.status = "Message not sent"
+		default:
+			m.editor.update(msg)
+		}
+		return m, nil
+	}
+
+	if m.confirm != "" {
+		action := m.confirm
+		m.confirm = ""
+		if msg.String() != "y" {
+			m.status = "Canceled"
+			return m, nil
+		}
+		// the selection moves on when the session ends while waiting for y
+		target, ok := m.session(m.target)
+		if !ok {
+			m.status = "That session has ended, nothing was done"
+			return m, nil
+		}
+		if action == "kick" {
+			m.status = "Disconnecting..."
+			return m, m.run(func() error { return m.actions.Kick(target.ID) },
+				fmt.Sprintf("Disconnected %s from %s", target.User, target.IP))
+		}
+		m.status = "Banning..."
+		return m, m.run(func() error { return m.actions.Ban(target.IP) },
+			fmt.Sprintf("Banned %s, its sessions were disconnected", target.IP))
+	}
+
+	switch msg.String() {
+	case "q", "esc":
+		return m, tea.Quit
+	case "up", "k":
+		if i > 0 {
+			m.selected = m.sessions[i-1].ID
+		}
+	case "down", "j":
+		if i >= 0 && i < len(m.sessions)-1 {
+			m.selected = m.sessions[i+1].ID
+		}
+	case "r":
+		m.refresh()
+		m.status = "Refreshed"
+	case "a":
+		m.compose("broadcast", "", "announcement to everyone • enter: send • esc: cancel")
+	case "M":
+		m.compose("maintenance", "", "maintenance banner, empty to clear • enter: set • esc: cancel")
+	case "d", "b", "m":
+		if i < 0 {
+			m.status = "No session selected"
+			break
+		}
+		target := m.sessions[i]
+		m.target = target.ID
+		switch msg.String() {
+		case "d":
+			m.confirm = "kick"
+			m.status = fmt.Sprintf("Disconnect %s from %s? y/n", target.User, target.IP)
+		case "b":
+			m.confirm = "ban"
+			m.status = fmt.Sprintf("Ban %s and disconnect its sessions? y/n", target.IP)
+		case "m":
+			m.compose("message", "", fmt.Sprintf("message to %s • enter: send • esc: cancel", target.IP))
+		}
+	}
+	return m, nil
+}
+
+func (m *ConsoleModel) compose(kind, text, status string) {
+	m.composing = kind
+	m.editor = newLineEditor(text)
+	m.status = status
+}
+
+// session returns the listed session with id
+func (m ConsoleModel) session(id string) (ConsoleSession, bool) {
+	for _, s := range m.sessions {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return ConsoleSession{}, false
+}
+
+// send delivers the composed text
+func (m ConsoleModel) send() (tea.Model, tea.Cmd) {
+	kind := m.composing
+	m.composing = ""
+	text := strings.TrimSpace(m.editor.String())
+
+	switch kind {
+	case "maintenance":
+		m.status = "Updating..."
+		done := "Maintenance banner set"
+		if text == "" {
+			done = "Maintenance banner cleared"
+		}
+		return m, m.run(func() error { return m.actions.Maintenance(text) }, done)
+	case "broadcast":
+		if text == "" {
+			m.status = "Announcement not sent"
+			return m, nil
+		}
+		m.status = "Sending..."
+		return m, m.run(func() error { return m.actions.Broadcast(text) }, "Announcement sent")
+	default:
+		target, ok := m.session(m.target)
+		if !ok {
+			m.status = "That session has ended, message not sent"
+			return m, nil
+		}
+		if text == "" {
+			m.status = "Message not sent"
+			return m, nil
+		}
+		m.status = "Sending..."
+		return m, m.run(func() error { return m.actions.Message(target.ID, text) },
+			fmt.Sprintf("Message sent to %s", target.IP))
+	}
+}
+
+// run performs an action off the program goroutine, since it may wait
+// for other sessions
+func (m ConsoleModel) run(action func() error, done string) tea.Cmd {
+	return func() tea.Msg {
+		if err := action(); err != nil {
+			return consoleDoneMsg{status: fmt.Sprintf("Failed: %v", err)}
+		}
+		return consoleDoneMsg{status: done}
+	}
+}
+
+// console table columns and their widths
+var consoleColumns = []struct {
+	title string
+	width int
+}{
+	{"USER", 12}, {"IP", 18}, {"VIA", 7}, {"TERM", 16}, {"SIZE", 8}, {"SECTION", 14}, {"TIME", 9},
+}
+
+func (m ConsoleModel) View() string {
+	if m.Width == 0 {
+		return "Loading..."
+	}
+
+	st := CurrentStyles()
+	width := m.Width - 4
+	var b strings.Builder
+
+	title := fmt.Sprintf("%s %s %s", st.Ornament.Render("◇"),
+		lipgloss.NewStyle().Foreground(st.Primary).Render("live sessions"), st.Ornament.Render("◇"))
+	b.WriteString(st.Title.Copy().Width(width).Render(title) + "\n")
+
+	header := make([]string, len(consoleColumns))
+	for i, col := range consoleColumns {
+		header[i] = col.title
+	}
+	b.WriteString("  " + st.SectionHeader.Render(consoleRow(header)) + "\n")
+
+	// leave room for the title, header, message line, footer and status bar
+	rows := m.Height - 10
+	if rows < 1 {
+		rows = 1
+	}
+	if len(m.sessions) == 0 {
+		b.WriteString(st.Inactive.Render("  nobody is connected") + "\n")
+	}
+	for i, s := range m.sessions {
+		if i == rows {
+			b.WriteString(st.Inactive.Render(fmt.Sprintf("  and %d more", len(m.sessions)-rows)) + "\n")
+			break
+		}
+		row := consoleRow([]string{
+			s.User, s.IP, s.Transport, s.Term, fmt.Sprintf("%dx%d", s.Width, s.Height),
+			s.Section, time.Since(s.Started).Round(time.Second).String(),
+		})
+		if s.ID == m.selected {
+			b.WriteString(st.Focused.Render("▸ "+row) + "\n")
+		} else {
+			b.WriteString("  " + st.Base.Render(row) + "\n")
+		}
+	}
+
+	if m.composing != "" {
+		b.WriteString("\n  " + m.composing + ": " + m.editor.View() + "\n")
+	}
+
+	helpText := "↑/↓: select • d: disconnect • b: ban IP • m: message • a: announce • M: maintenance • q: quit"
+	if m.composing != "" {
+		helpText = "type the text • enter: send • esc: cancel"
+	}
+	footer := st.Footer.Copy().Width(width).Render(helpText)
+	statusBar := st.StatusBar.Render(st.RenderStatusBar("CONSOLE",
+		fmt.Sprintf("%s • %d connected", m.status, len(m.sessions)), width))
+
+	body := lipgloss.NewStyle().Height(m.Height - 6).Render(b.String())
+	return lipgloss.NewStyle().Padding(1, 2).Render(body + "\n" + footer + "\n" + statusBar)
+}
+
+// consoleRow pads or cuts each cell to its column width
+func consoleRow(cells []string) string {
+	var b strings.Builder
+	for i, cell := range cells {
+		w := consoleColumns[i].width
+		if r := []rune(cell); len(r) > w-1 {
+			cell = string(r[:w-2]) + "…"
+		}
+		b.WriteString(cell + strings.Repeat(" ", w-len([]rune(cell))))
+	}
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// fakeSessions backs a console with a list the test changes
+type fakeSessions struct {
+	list    []ConsoleSession
+	kicked  []string
+	banned  []string
+	message []string
+}
+
+func (f *fakeSessions) actions() ConsoleActions {
+	return ConsoleActions{
+		List: func() []ConsoleSession { return f.list },
+		Kick: func(id string) error { f.kicked = append(f.kicked, id); return nil },
+		Ban:  func(ip string) error { f.banned = append(f.banned, ip); return nil },
+		Message: func(id, text string) error {
+			f.message = append(f.message, id)
+			return nil
+		},
+		Broadcast:   func(string) error { return nil },
+		Maintenance: func(string) error { return nil },
+	}
+}
+
+func press(m ConsoleModel, keys ...string) ConsoleModel {
+	for _, key := range keys {
+		var msg tea.KeyMsg
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		}
+		model, cmd := m.Update(msg)
+		m = model.(ConsoleModel)
+		if cmd != nil {
+			model, _ = m.Update(cmd())
+			m = model.(ConsoleModel)
+		}
+	}
+	return m
+}
+
+func TestConsoleActionsFollowTheirSession(t *testing.T) {
+	alice := ConsoleSession{ID: "1", User: "alice", IP: "192.0.2.1"}
+	bob := ConsoleSession{ID: "2", User: "bob", IP: "192.0.2.2"}
+
+	tests := []struct {
+		name    string
+		start   []string // keys pressed with bob selected
+		finish  []string // keys pressed after the list changed
+		list    []ConsoleSession
+		kicked  []string
+		banned  []string
+		message []string
+	}{
+		{"kick", []string{"d"}, []string{"y"}, []ConsoleSession{alice, bob}, []string{"2"}, nil, nil},
+		{"kick ended session", []string{"d"}, []string{"y"}, []ConsoleSession{alice}, nil, nil, nil},
+		{"ban", []string{"b"}, []string{"y"}, []ConsoleSession{bob, alice}, nil, []string{"192.0.2.2"}, nil},
+		{"ban ended session", []string{"b"}, []string{"y"}, []ConsoleSession{alice}, nil, nil, nil},
+		{"message", []string{"m", "h", "i"}, []string{"enter"}, []ConsoleSession{alice, bob}, nil, nil, []string{"2"}},
+		{"message ended session", []string{"m", "h", "i"}, []string{"enter"}, []ConsoleSession{alice}, nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeSessions{list: []ConsoleSession{alice, bob}}
+			m := NewConsoleModel(f.actions(), 120, 40)
+			m = press(m, "j")
+			m = press(m, tt.start...)
+
+			f.list = tt.list
+			model, _ := m.Update(consoleTickMsg{})
+			m = press(model.(ConsoleModel), tt.finish...)
+
+			if !equal(f.kicked, tt.kicked) || !equal(f.banned, tt.banned) || !equal(f.message, tt.message) {
+				t.Errorf("kicked %v, banned %v, messaged %v, want %v, %v, %v",
+					f.kicked, f.banned, f.message, tt.kicked, tt.banned, tt.message)
+			}
+		})
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package tui
+
+import "time"
+
+// kinds of events reported through Model.OnEvent
+const (
+	EventSectionEnter = "section_enter" // a section became visible
+	EventSectionLeave = "section_leave" // a section was left, with the dwell time
+	EventLinkMode     = "link_mode"     // link mode was toggled
+	EventLinkActivate = "link_activate" // a link was opened with enter
+)
+
+// Event describes one visitor interaction with the model
+type Event struct {
+	Kind    string
+	Section string
+	Link    string        // activated link
+	Dwell   time.Duration // time spent in the section, for EventSectionLeave
+	Enabled bool          // new link mode state, for EventLinkMode
+	Time    time.Time
+}
+
+// emit reports an event for the current section to the OnEvent hook, if
+// any. The hook runs on the program goroutine and must not block.
+func (m Model) emit(e Event) {
+	if m.OnEvent == nil {
+		return
+	}
+	if m.SectionCursor < len(m.Portfolio.Sections) {
+		e.Section = m.Portfolio.Sections[m.SectionCursor].Title
+	}
+	e.Time = time.Now()
+	m.OnEvent(e)
+}
+
+// emitLeave reports how long the current section was shown
+func (m Model) emitLeave() {
+	m.emit(Event{Kind: EventSectionLeave, Dwell: time.Since(m.sectionEntered)})
+}
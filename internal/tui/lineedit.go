@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// lineEditor is a single line text input with emacs-style movement keys.
+// Enter and esc are left to the caller.
+type lineEditor struct {
+	buffer []rune
+	cursor int // position in buffer
+}
+
+func newLineEditor(text string) lineEditor {
+	buffer := []rune(text)
+	return lineEditor{buffer: buffer, cursor: len(buffer)}
+}
+
+func (e lineEditor) String() string {
+	return string(e.buffer)
+}
+
+// update applies an editing key
+func (e *lineEditor) update(msg tea.KeyMsg) {
+	buf, cur := e.buffer, e.cursor
+
+	switch msg.Type {
+	case tea.KeyBackspace:
+		if cur > 0 {
+			buf = append(buf[:cur-1], buf[cur:]...)
+			cur--
+		}
+	case tea.KeyDelete, tea.KeyCtrlD:
+		if cur < len(buf) {
+			buf = append(buf[:cur], buf[cur+1:]...)
+		}
+	case tea.KeyLeft, tea.KeyCtrlB:
+		if cur > 0 {
+			cur--
+		}
+	case tea.KeyRight, tea.KeyCtrlF:
+		if cur < len(buf) {
+			cur++
+		}
+	case tea.KeyHome, tea.KeyCtrlA:
+		cur = 0
+	case tea.KeyEnd, tea.KeyCtrlE:
+		cur = len(buf)
+	case tea.KeyCtrlU:
+		buf = buf[cur:]
+		cur = 0
+	case tea.KeySpace:
+		buf = insertRunes(buf, cur, []rune{' '})
+		cur++
+	case tea.KeyRunes:
+		buf = insertRunes(buf, cur, msg.Runes)
+		cur += len(msg.Runes)
+	}
+	e.buffer, e.cursor = buf, cur
+}
+
+func insertRunes(buf []rune, at int, runes []rune) []rune {
+	out := make([]rune, 0, len(buf)+len(runes))
+	out = append(out, buf[:at]...)
+	out = append(out, runes...)
+	return append(out, buf[at:]...)
+}
+
+// View shows the text with a block cursor
+func (e lineEditor) View() string {
+	cursorStyle := lipgloss.NewStyle().Reverse(true)
+	buf := []rune(strings.ReplaceAll(string(e.buffer), "\n", "↵"))
+
+	under, after := " ", ""
+	if e.cursor < len(buf) {
+		under = string(buf[e.cursor])
+		after = string(buf[e.cursor+1:])
+	}
+	return string(buf[:e.cursor]) + cursorStyle.Render(under) + after
+}
@@ -24,7 +24,26 @@ type Model struct {
 	StatusMode    string           // Status bar mode indicator
 	StatusMessage string           // Status bar message
 	ShowWelcome   bool             // Whether to show the welcome screen
+	Broadcast     string           // Announcement banner, dismissible
+	Maintenance   string           // Maintenance banner, shown until cleared
 	Portfolio     models.Portfolio // Portfolio data
+
+	IdleTimeout    time.Duration // Quit after no keypress for this long, 0 disables
+	TimeoutWarning time.Duration // Show a countdown this long before a timeout
+	Deadline       time.Time     // Quit at this time, zero disables
+	TimeoutMessage string        // Countdown shown in the status bar
+	ExitReason     string        // Why the program quit
+
+	OnEvent func(Event) // Receives navigation events, optional
+
+	WhatsNew []models.SectionChange // Changes since the visitor's last session, shown until a key is pressed
+
+	Owner  bool                         // Whether admin mode can be entered
+	OnSave func(models.Portfolio) error // Persists content edited in admin mode
+
+	lastInput      time.Time  // Time of the last keypress
+	sectionEntered time.Time  // Time the current section was shown
+	admin          adminState // Admin mode, owners only
 }
 
 // message when a URL should be opened
@@ -33,28 +52,36 @@ type openURLMsg string
 // message to indicate the welcome screen should be dismissed
 type welcomeDoneMsg struct{}
 
-// initializes a new TUI model
-func NewModel(width, height int) Model {
-	portfolio := models.GetPortfolio()
+// KickMsg ends the session, sent when the owner disconnects it
+type KickMsg struct{}
 
-	// create tab titles from section titles
-	var tabTitles []string
-	for _, sec := range portfolio.Sections {
-		tabTitles = append(tabTitles, sec.Title)
-	}
+// NoticeMsg is a private message from the owner, shown in the status bar
+type NoticeMsg string
+
+// BroadcastMsg is an announcement to every visitor, shown as a banner
+type BroadcastMsg string
 
+// MaintenanceMsg sets the maintenance banner, an empty one clears it
+type MaintenanceMsg string
+
+// initializes a new TUI model
+func NewModel(portfolio models.Portfolio, width, height int) Model {
 	m := Model{
 		SectionCursor: 0,
 		LinkCursor:    0,
 		InLinkMode:    false,
-		TabTitles:     tabTitles,
 		Width:         width,
 		Height:        height,
 		StatusMode:    "NORMAL",
 		StatusMessage: "Ready",
 		ShowWelcome:   true,
 		Portfolio:     portfolio,
+		lastInput:     time.Now(),
 	}
+	m.sectionEntered = m.lastInput
+
+	// create tab titles from section titles
+	m.refreshTabs()
 
 	// get links for initial section
 	if len(portfolio.Sections) > 0 {
@@ -82,9 +109,11 @@ func openURLCommand(url string) tea.Cmd {
 }
 
 func (m Model) Init() tea.Cmd {
+	m.emit(Event{Kind: EventSectionEnter})
 	return tea.Batch(
 		tea.ClearScreen,
 		welcomeScreenTimer(),
+		m.timeoutCheck(time.Now()),
 	)
 }
 
@@ -95,22 +124,64 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// time to dismiss the welcome screen
 		m.ShowWelcome = false
 		return m, nil
+	case timeoutTickMsg:
+		return m.handleTimeoutTick(time.Time(msg))
+	case contentSavedMsg:
+		return m.handleContentSaved(msg), nil
+	case KickMsg:
+		m.ExitReason = ExitKicked
+		m.emitLeave()
+		return m, tea.Quit
+	case NoticeMsg:
+		m.ShowWelcome = false
+		m.StatusMode = "MESSAGE"
+		m.StatusMessage = string(msg)
+		return m, nil
+	case BroadcastMsg:
+		m.Broadcast = string(msg)
+		return m, nil
+	case MaintenanceMsg:
+		m.Maintenance = string(msg)
+		return m, nil
 	case tea.KeyMsg:
+		m.lastInput = time.Now()
+		m.TimeoutMessage = ""
+
 		// dismiss welcome screen immediately on any key press
 		if m.ShowWelcome {
 			m.ShowWelcome = false
 			return m, nil
 		}
 
+		if m.admin.active {
+			return m.updateAdmin(msg)
+		}
+
+		// any key but quit closes the what's new panel
+		if key := msg.String(); len(m.WhatsNew) > 0 && key != "q" && key != "ctrl+c" {
+			m.WhatsNew = nil
+			return m, nil
+		}
+
 		switch msg.String() {
 		case "q", "ctrl+c":
+			m.ExitReason = ExitQuit
+			m.emitLeave()
 			return m, tea.Quit
+		case "a":
+			if m.Owner {
+				m.enterAdmin()
+			}
+		case "esc":
+			// dismiss the announcement
+			m.Broadcast = ""
 		case "tab":
 			// only toggle link mode if current section has links
 			currentSectionLinks := FindLinks(m.Portfolio.Sections[m.SectionCursor].Content)
 			if len(currentSectionLinks) > 0 {
 				m.InLinkMode = !m.InLinkMode
 				m.Links = currentSectionLinks
+				m.emit(Event{Kind: EventLinkMode, Enabled: m.InLinkMode})
 
 				if m.InLinkMode {
 					m.StatusMode = "LINK"
@@ -134,12 +205,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			} else {
 				// Navigate sections
 				if m.SectionCursor < len(m.Portfolio.Sections)-1 {
-					m.SectionCursor++
-					// Update links for the new section
-					m.Links = FindLinks(m.Portfolio.Sections[m.SectionCursor].Content)
-					m.InLinkMode = false
-					m.StatusMode = "NORMAL"
-					m.StatusMessage = fmt.Sprintf("Section: %s", m.Portfolio.Sections[m.SectionCursor].Title)
+					m.showSection(m.SectionCursor + 1)
 				}
 			}
 		case "k", "up":
@@ -152,18 +218,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			} else {
 				// Navigate sections
 				if m.SectionCursor > 0 {
-					m.SectionCursor--
-					// Update links for the new section
-					m.Links = FindLinks(m.Portfolio.Sections[m.SectionCursor].Content)
-					m.InLinkMode = false
-					m.StatusMode = "NORMAL"
-					m.StatusMessage = fmt.Sprintf("Section: %s", m.Portfolio.Sections[m.SectionCursor].Title)
+					m.showSection(m.SectionCursor - 1)
 				}
 			}
 		case "enter":
 			if m.InLinkMode && m.LinkCursor < len(m.Links) {
 				// Open the selected link in a browser
 				m.StatusMessage = fmt.Sprintf("Opening: %s", m.Links[m.LinkCursor])
+				m.emit(Event{Kind: EventLinkActivate, Link: m.Links[m.LinkCursor]})
 				return m, openURLCommand(m.Links[m.LinkCursor])
 			}
 		}
@@ -178,8 +240,35 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// showSection switches to section i and reports the change
+func (m *Model) showSection(i int) {
+	m.emitLeave()
+	m.SectionCursor = i
+	m.sectionEntered = time.Now()
+	// Update links for the new section
+	m.Links = FindLinks(m.Portfolio.Sections[i].Content)
+	m.InLinkMode = false
+	m.StatusMode = "NORMAL"
+	m.StatusMessage = fmt.Sprintf("Section: %s", m.Portfolio.Sections[i].Title)
+	m.emit(Event{Kind: EventSectionEnter})
+}
+
+// refreshTabs rebuilds the tab titles after sections changed
+func (m *Model) refreshTabs() {
+	titles := make([]string, 0, len(m.Portfolio.Sections))
+	for _, sec := range m.Portfolio.Sections {
+		if sec.Hidden {
+			// only owners get hidden sections
+			titles = append(titles, "("+sec.Title+")")
+			continue
+		}
+		titles = append(titles, sec.Title)
+	}
+	m.TabTitles = titles
+}
+
 // welcome screen view
-func (m Model) renderWelcomeScreen() string {
+func (m Model) renderWelcomeScreen(st *Styles) string {
 	// Calculate centered position
 	width := m.Width
 	height := m.Height
@@ -187,8 +276,8 @@ func (m Model) renderWelcomeScreen() string {
 	// Simple welcome message
 	welcomeMsg := "━━━ " + m.Portfolio.Title + " ━━━"
 
-	// Use the consolidated WelcomeTextStyle from styles.go
-	styledMsg := WelcomeTextStyle.Render(welcomeMsg)
+	// Use the consolidated WelcomeText style from styles.go
+	styledMsg := st.WelcomeText.Render(welcomeMsg)
 
 	// Center the message in the terminal
 	centeredMsg := lipgloss.Place(
@@ -209,9 +298,12 @@ func (m Model) View() string {
 		return "Loading..."
 	}
 
+	// an owner may save a new theme while this renders
+	st := CurrentStyles()
+
 	// Show welcome screen if needed
 	if m.ShowWelcome {
-		return m.renderWelcomeScreen()
+		return m.renderWelcomeScreen(st)
 	}
 
 	// Calculate container dimensions
@@ -219,12 +311,12 @@ func (m Model) View() string {
 	contentWidth := containerWidth - 8
 
 	// Ornaments for the title using style from styles.go
-	leftOrnament := OrnamentStyle.Render("◇")
-	rightOrnament := OrnamentStyle.Render("◇")
-	title := lipgloss.NewStyle().Foreground(PrimaryColor).Render(m.Portfolio.Title)
+	leftOrnament := st.Ornament.Render("◇")
+	rightOrnament := st.Ornament.Render("◇")
+	title := lipgloss.NewStyle().Foreground(st.Primary).Render(m.Portfolio.Title)
 
 	titleContent := fmt.Sprintf("%s %s %s", leftOrnament, title, rightOrnament)
-	titleStr := TitleStyle.Copy().
+	titleStr := st.Title.Copy().
 		Width(contentWidth).
 		Align(lipgloss.Center).
 		MarginBottom(0).
@@ -242,7 +334,7 @@ func (m Model) View() string {
 	tabsStr := lipgloss.NewStyle().
 		MarginTop(1).
 		MarginBottom(1).
-		Render(RenderTabs(m.TabTitles, m.SectionCursor, contentWidth))
+		Render(st.RenderTabs(m.TabTitles, m.SectionCursor, contentWidth))
 
 	// Get current section content
 	currentSection := m.Portfolio.Sections[m.SectionCursor]
@@ -250,98 +342,129 @@ func (m Model) View() string {
 	// Content container with section header
 	contentBuilder := strings.Builder{}
 
-	// Use SectionHeaderStyle from styles.go
-	sectionHeader := SectionHeaderStyle.Render("✦" + strings.ToUpper(currentSection.Title) + "✦")
-	contentBuilder.WriteString(sectionHeader + "\n")
-
-	// Use SectionDividerStyle from styles.go
-	contentBuilder.WriteString(SectionDividerStyle.Render(strings.Repeat("─", contentWidth/2)) + "\n\n")
-
-	// Process section content
-	for _, line := range currentSection.Content {
-		processedLine := line
-
-		// Extract and style links
-		if m.InLinkMode {
-			// In link mode, highlight and make links selectable
-			re := regexp.MustCompile(`(https?://\S+)`)
-			matches := re.FindAllStringIndex(line, -1)
-
-			// Process matches from right to left to avoid index shifts
-			for j := len(matches) - 1; j >= 0; j-- {
-				match := matches[j]
-				linkText := line[match[0]:match[1]]
-
-				// Check if this link is selected
-				isSelected := false
-				for linkIdx, l := range m.Links {
-					if l == linkText && linkIdx == m.LinkCursor {
-						isSelected = true
-						break
+	if m.admin.active {
+		contentBuilder.WriteString(m.renderAdminSection(st, contentWidth))
+	} else if len(m.WhatsNew) > 0 {
+		contentBuilder.WriteString(m.renderWhatsNew(st, contentWidth))
+	} else {
+		// Use the SectionHeader style from styles.go
+		sectionHeader := st.SectionHeader.Render("✦" + strings.ToUpper(currentSection.Title) + "✦")
+		contentBuilder.WriteString(sectionHeader + "\n")
+
+		// Use the SectionDivider style from styles.go
+		contentBuilder.WriteString(st.SectionDivider.Render(strings.Repeat("─", contentWidth/2)) + "\n\n")
+
+		// Process section content
+		for _, line := range currentSection.Content {
+			processedLine := line
+
+			// Extract and style links
+			if m.InLinkMode {
+				// In link mode, highlight and make links selectable
+				re := regexp.MustCompile(`(https?://\S+)`)
+				matches := re.FindAllStringIndex(line, -1)
+
+				// Process matches from right to left to avoid index shifts
+				for j := len(matches) - 1; j >= 0; j-- {
+					match := matches[j]
+					linkText := line[match[0]:match[1]]
+
+					// Check if this link is selected
+					isSelected := false
+					for linkIdx, l := range m.Links {
+						if l == linkText && linkIdx == m.LinkCursor {
+							isSelected = true
+							break
+						}
 					}
-				}
 
-				var styledLink string
-				if isSelected {
-					styledLink = SelectedLinkStyle.Copy().
-						Bold(true).
-						Underline(true).
-						Render("→ " + linkText)
-				} else {
-					styledLink = LinkStyle.Render(linkText)
-				}
+					var styledLink string
+					if isSelected {
+						styledLink = st.SelectedLink.Copy().
+							Bold(true).
+							Underline(true).
+							Render("→ " + linkText)
+					} else {
+						styledLink = st.Link.Render(linkText)
+					}
 
-				// Replace the original link with the styled version
-				processedLine = processedLine[:match[0]] + styledLink + processedLine[match[1]:]
-			}
-		} else {
-			re := regexp.MustCompile(`(https?://\S+)`)
-			matches := re.FindAllStringIndex(line, -1)
-
-			for j := len(matches) - 1; j >= 0; j-- {
-				match := matches[j]
-				linkText := line[match[0]:match[1]]
-				var styledLink string
-				styledLink = LinkStyle.Render(linkText)
-				processedLine = processedLine[:match[0]] + styledLink + processedLine[match[1]:]
+					// Replace the original link with the styled version
+					processedLine = processedLine[:match[0]] + styledLink + processedLine[match[1]:]
+				}
+			} else {
+				re := regexp.MustCompile(`(https?://\S+)`)
+				matches := re.FindAllStringIndex(line, -1)
+
+				for j := len(matches) - 1; j >= 0; j-- {
+					match := matches[j]
+					linkText := line[match[0]:match[1]]
+					var styledLink string
+					styledLink = st.Link.Render(linkText)
+					processedLine = processedLine[:match[0]] + styledLink + processedLine[match[1]:]
+				}
 			}
-		}
 
-		// Add the processed line to content
-		contentBuilder.WriteString("  " + processedLine + "\n")
+			// Add the processed line to content
+			contentBuilder.WriteString("  " + processedLine + "\n")
+		}
 	}
 
 	// Style the content area with fixed height from styles.go
 	contentStr := lipgloss.NewStyle().
 		Height(ContentHeight).
-		Render(SectionContentStyle.Render(contentBuilder.String()))
+		Render(st.SectionContent.Render(contentBuilder.String()))
 
 	var helpText string
-	if m.InLinkMode {
+	if m.admin.active {
+		helpText = m.adminHelp()
+	} else if len(m.WhatsNew) > 0 {
+		helpText = "any key: continue • q: quit"
+	} else if m.InLinkMode {
 		helpText = "↑/↓: navigate links • enter: open link • tab: exit link mode • q: quit"
 	} else {
 		helpText = "↑/↓ : navigate sections"
 		if len(m.Links) > 0 {
 			helpText += " • tab: enter link mode"
 		}
+		if m.Broadcast != "" {
+			helpText += " • esc: dismiss"
+		}
+		if m.Owner {
+			helpText += " • a: admin"
+		}
 		helpText += " • q: quit"
 	}
 
-	footer := FooterStyle.
+	footer := st.Footer.Copy().
 		Width(contentWidth).
 		Render(helpText)
 
-	statusBar := StatusBarStyle.
-		Render(RenderStatusBar(m.StatusMode, m.StatusMessage, contentWidth))
+	statusMode, statusMessage := m.StatusMode, m.StatusMessage
+	if m.TimeoutMessage != "" {
+		statusMode, statusMessage = "TIMEOUT", m.TimeoutMessage
+	}
+
+	statusBar := st.StatusBar.
+		Render(st.RenderStatusBar(statusMode, statusMessage, contentWidth))
+
+	// banners go between the title and the tab bar
+	var banners string
+	if m.Maintenance != "" {
+		banners += st.MaintenanceBanner.Copy().Width(contentWidth).Render("⚠ "+m.Maintenance) + "\n"
+	}
+	if m.Broadcast != "" {
+		banners += st.BroadcastBanner.Copy().Width(contentWidth).Render("✉ "+m.Broadcast) + "\n"
+	}
 
-	contentArea := fmt.Sprintf("%s\n%s\n%s\n%s\n%s",
+	contentArea := fmt.Sprintf("%s\n%s%s\n%s\n%s\n%s",
 		titleStr,
+		banners,
 		tabsStr,
 		contentStr,
 		footer,
 		statusBar)
 
-	wrappedView := ContainerStyle.
+	wrappedView := st.Container.Copy().
 		Width(containerWidth).
 		Render(contentArea)
 
@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+func TestBanners(t *testing.T) {
+	tests := []struct {
+		name     string
+		msgs     []tea.Msg
+		shown    []string
+		notShown []string
+	}{
+		{"broadcast", []tea.Msg{BroadcastMsg("hello all")}, []string{"hello all"}, nil},
+		{"broadcast dismissed", []tea.Msg{BroadcastMsg("hello all"), tea.KeyMsg{Type: tea.KeyEsc}}, nil, []string{"hello all"}},
+		{"maintenance", []tea.Msg{MaintenanceMsg("restarting soon")}, []string{"restarting soon"}, nil},
+		{"maintenance stays on esc", []tea.Msg{MaintenanceMsg("restarting soon"), tea.KeyMsg{Type: tea.KeyEsc}}, []string{"restarting soon"}, nil},
+		{"maintenance cleared", []tea.Msg{MaintenanceMsg("restarting soon"), MaintenanceMsg("")}, nil, []string{"restarting soon"}},
+		{"both", []tea.Msg{MaintenanceMsg("restarting soon"), BroadcastMsg("hello all")}, []string{"restarting soon", "hello all"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := NewModel(models.DefaultPortfolio(), 120, 40)
+			model.ShowWelcome = false
+			var m tea.Model = model
+			for _, msg := range tt.msgs {
+				m, _ = m.Update(msg)
+			}
+			view := m.View()
+			for _, text := range tt.shown {
+				if !strings.Contains(view, text) {
+					t.Errorf("%q not shown", text)
+				}
+			}
+			for _, text := range tt.notShown {
+				if strings.Contains(view, text) {
+					t.Errorf("%q still shown", text)
+				}
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"strings"
+	"sync/atomic"
 
 	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
 	"github.com/charmbracelet/lipgloss"
@@ -9,22 +10,13 @@ import (
 
 // Style management for the entire application
 
-// Portfolio instance to access theme data
-var portfolio = models.GetPortfolio()
-
 // Color palette - all colors used in the application should be defined here
 var (
 	BaseColor       = lipgloss.Color("#282c34")
-	PrimaryColor    = lipgloss.Color(portfolio.Theme.Primary)
-	AccentColor     = lipgloss.Color(portfolio.Theme.Accent)
 	SuccessColor    = lipgloss.Color("#98c379") // Green
 	WarningColor    = lipgloss.Color("#e5c07b") // Yellow
 	DangerColor     = lipgloss.Color("#e06c75") // Red
-	TextColor       = lipgloss.Color(portfolio.Theme.Text)
-	SubtleColor     = lipgloss.Color(portfolio.Theme.Subtle)
 	BackgroundColor = lipgloss.Color("#1e222a")
-	HighlightColor  = lipgloss.Color(portfolio.Theme.Links)
-	SelectionColor  = lipgloss.Color(portfolio.Theme.Selection)
 	LinkBackground  = lipgloss.Color("#2a3040")
 )
 
@@ -36,152 +28,217 @@ var (
 	ContentHeight    = 16
 )
 
-// Base text styles
-var (
+// Styles are the color palette and every style built from one theme. They
+// are never changed once built, sessions render with whichever Styles were
+// current when their View started while SetTheme swaps in new ones.
+type Styles struct {
+	Primary   lipgloss.Color
+	Accent    lipgloss.Color
+	Text      lipgloss.Color
+	Subtle    lipgloss.Color
+	Highlight lipgloss.Color
+	Selection lipgloss.Color
+
+	Base              lipgloss.Style
+	App               lipgloss.Style
+	Title             lipgloss.Style
+	Content           lipgloss.Style
+	WelcomeText       lipgloss.Style
+	TabBar            lipgloss.Style
+	ActiveTab         lipgloss.Style
+	InactiveTab       lipgloss.Style
+	Focused           lipgloss.Style
+	Inactive          lipgloss.Style
+	Link              lipgloss.Style
+	SelectedLink      lipgloss.Style
+	StatusBar         lipgloss.Style
+	ModeIndicator     lipgloss.Style
+	StatusMessage     lipgloss.Style
+	SectionContent    lipgloss.Style
+	Item              lipgloss.Style
+	HighlightedItem   lipgloss.Style
+	SectionHeader     lipgloss.Style
+	SectionDivider    lipgloss.Style
+	Footer            lipgloss.Style
+	Ornament          lipgloss.Style
+	Container         lipgloss.Style
+	BroadcastBanner   lipgloss.Style
+	MaintenanceBanner lipgloss.Style
+}
+
+var styles atomic.Pointer[Styles]
+
+func init() {
+	SetTheme(models.DefaultPortfolio().Theme)
+}
+
+// CurrentStyles returns the styles of the current theme. Callers must copy a
+// style before changing it, styles share their rules with every session.
+func CurrentStyles() *Styles {
+	return styles.Load()
+}
+
+// SetTheme builds the color palette and every style from a portfolio theme.
+// Running sessions pick it up on their next render.
+func SetTheme(theme models.Theme) {
+	styles.Store(newStyles(theme))
+}
+
+func newStyles(theme models.Theme) *Styles {
+	st := &Styles{}
+	st.Primary = lipgloss.Color(theme.Primary)
+	st.Accent = lipgloss.Color(theme.Accent)
+	st.Text = lipgloss.Color(theme.Text)
+	st.Subtle = lipgloss.Color(theme.Subtle)
+	st.Highlight = lipgloss.Color(theme.Links)
+	st.Selection = lipgloss.Color(theme.Selection)
+
 	// Base text style
-	BaseStyle = lipgloss.NewStyle().
-			Foreground(TextColor)
+	st.Base = lipgloss.NewStyle().
+		Foreground(st.Text)
 
 	// App container style
-	AppStyle = lipgloss.NewStyle().
-			Border(lipgloss.NormalBorder()).
-			BorderForeground(PrimaryColor).
-			Padding(1, 2).
-			BorderBottom(true)
+	st.App = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(st.Primary).
+		Padding(1, 2).
+		BorderBottom(true)
 
 	// Title style for the application header
-	TitleStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(AccentColor).
-			PaddingBottom(1).
-			MarginBottom(1).
-			Italic(true).
-			Border(lipgloss.Border{
+	st.Title = lipgloss.NewStyle().
+		Bold(true).
+		Foreground(st.Accent).
+		PaddingBottom(1).
+		MarginBottom(1).
+		Italic(true).
+		Border(lipgloss.Border{
 			Bottom: "━",
 		}).
-		BorderForeground(PrimaryColor)
+		BorderForeground(st.Primary)
 
 	// Content container style
-	ContentStyle = lipgloss.NewStyle().
-			Padding(1, 2).
-			MarginTop(1)
-)
+	st.Content = lipgloss.NewStyle().
+		Padding(1, 2).
+		MarginTop(1)
 
-// Welcome screen styles
-var (
-	WelcomeTextStyle = lipgloss.NewStyle().
+	// Welcome screen styles
+	st.WelcomeText = lipgloss.NewStyle().
 		Bold(true).
-		Foreground(HighlightColor)
-)
+		Foreground(st.Highlight)
 
-// Tab styles
-var (
 	// Tab bar styles
-	TabBarStyle = lipgloss.NewStyle().
-			Border(lipgloss.NormalBorder(), false, false, true).
-			BorderForeground(PrimaryColor)
-
-	ActiveTabStyle = lipgloss.NewStyle().
-			Foreground(AccentColor).
-			Background(BaseColor).
-			Bold(true).
-			Padding(0, 2).
-			Border(lipgloss.Border{
+	st.TabBar = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, false, true).
+		BorderForeground(st.Primary)
+
+	st.ActiveTab = lipgloss.NewStyle().
+		Foreground(st.Accent).
+		Background(BaseColor).
+		Bold(true).
+		Padding(0, 2).
+		Border(lipgloss.Border{
 			Bottom: "─",
 		}, false, false, true).
-		BorderForeground(AccentColor)
+		BorderForeground(st.Accent)
 
-	InactiveTabStyle = lipgloss.NewStyle().
-				Foreground(TextColor).
-				Padding(0, 2)
-)
+	st.InactiveTab = lipgloss.NewStyle().
+		Foreground(st.Text).
+		Padding(0, 2)
 
-// Navigation styles
-var (
-	FocusedStyle = lipgloss.NewStyle().
-			Foreground(AccentColor).
-			Bold(true)
+	// Navigation styles
+	st.Focused = lipgloss.NewStyle().
+		Foreground(st.Accent).
+		Bold(true)
 
-	InactiveStyle = lipgloss.NewStyle().
-			Foreground(SubtleColor)
-)
+	st.Inactive = lipgloss.NewStyle().
+		Foreground(st.Subtle)
 
-// Link styles
-var (
-	LinkStyle = lipgloss.NewStyle().
-			Foreground(HighlightColor).
-			Underline(true)
-
-	SelectedLinkStyle = lipgloss.NewStyle().
-				Foreground(SelectionColor).
-				Background(LinkBackground).
-				Bold(true).
-				Underline(true)
-)
+	// Link styles
+	st.Link = lipgloss.NewStyle().
+		Foreground(st.Highlight).
+		Underline(true)
 
-// Status bar styles
-var (
-	StatusBarStyle = lipgloss.NewStyle().
-			Background(PrimaryColor).
-			Foreground(lipgloss.Color("#000000")).
-			Bold(true).
-			PaddingLeft(2).
-			PaddingRight(2)
-
-	ModeIndicatorStyle = lipgloss.NewStyle().
-				Background(AccentColor).
-				Foreground(lipgloss.Color("#000000")).
-				Bold(true).
-				Padding(0, 1)
-
-	StatusMessageStyle = lipgloss.NewStyle().
-				Background(SubtleColor).
-				Foreground(TextColor).
-				Italic(true).
-				Padding(0, 1)
-)
+	st.SelectedLink = lipgloss.NewStyle().
+		Foreground(st.Selection).
+		Background(LinkBackground).
+		Bold(true).
+		Underline(true)
+
+	// Status bar styles
+	st.StatusBar = lipgloss.NewStyle().
+		Background(st.Primary).
+		Foreground(lipgloss.Color("#000000")).
+		Bold(true).
+		PaddingLeft(2).
+		PaddingRight(2)
+
+	st.ModeIndicator = lipgloss.NewStyle().
+		Background(st.Accent).
+		Foreground(lipgloss.Color("#000000")).
+		Bold(true).
+		Padding(0, 1)
+
+	st.StatusMessage = lipgloss.NewStyle().
+		Background(st.Subtle).
+		Foreground(st.Text).
+		Italic(true).
+		Padding(0, 1)
 
-// Content styles
-var (
 	// Section content style
-	SectionContentStyle = lipgloss.NewStyle().
-				PaddingLeft(2).
-				MarginTop(1)
+	st.SectionContent = lipgloss.NewStyle().
+		PaddingLeft(2).
+		MarginTop(1)
 
 	// Item styles
-	ItemStyle = lipgloss.NewStyle().
-			PaddingLeft(2)
+	st.Item = lipgloss.NewStyle().
+		PaddingLeft(2)
 
-	HighlightedItemStyle = lipgloss.NewStyle().
-				Foreground(SuccessColor).
-				PaddingLeft(2)
+	st.HighlightedItem = lipgloss.NewStyle().
+		Foreground(SuccessColor).
+		PaddingLeft(2)
 
 	// Section header style
-	SectionHeaderStyle = lipgloss.NewStyle().
-				Foreground(PrimaryColor).
-				Bold(true)
+	st.SectionHeader = lipgloss.NewStyle().
+		Foreground(st.Primary).
+		Bold(true)
 
 	// Section divider style
-	SectionDividerStyle = lipgloss.NewStyle().
-				Foreground(SubtleColor)
+	st.SectionDivider = lipgloss.NewStyle().
+		Foreground(st.Subtle)
 
 	// Footer style
-	FooterStyle = lipgloss.NewStyle().
-			Border(lipgloss.Border{Top: "━"}).
-			BorderForeground(SubtleColor).
-			Padding(0, 1).
-			Align(lipgloss.Center)
+	st.Footer = lipgloss.NewStyle().
+		Border(lipgloss.Border{Top: "━"}).
+		BorderForeground(st.Subtle).
+		Padding(0, 1).
+		Align(lipgloss.Center)
 
 	// Title ornament style
-	OrnamentStyle = lipgloss.NewStyle().
-			Foreground(AccentColor)
+	st.Ornament = lipgloss.NewStyle().
+		Foreground(st.Accent)
 
 	// Main container style
-	ContainerStyle = lipgloss.NewStyle().
-			BorderStyle(lipgloss.NormalBorder()).
-			BorderForeground(PrimaryColor).
-			Padding(1, 2)
-)
+	st.Container = lipgloss.NewStyle().
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(st.Primary).
+		Padding(1, 2)
+
+	// Banner styles for announcements and maintenance notices
+	st.BroadcastBanner = lipgloss.NewStyle().
+		Background(st.Accent).
+		Foreground(lipgloss.Color("#000000")).
+		Bold(true).
+		Padding(0, 1).
+		MarginTop(1)
+
+	st.MaintenanceBanner = lipgloss.NewStyle().
+		Background(WarningColor).
+		Foreground(lipgloss.Color("#000000")).
+		Bold(true).
+		Padding(0, 1).
+		MarginTop(1)
+	return st
+}
 
 // TabBorder returns a customized tab border (straight)
 func TabBorder() lipgloss.Border {
@@ -198,7 +255,7 @@ func TabBorder() lipgloss.Border {
 }
 
 // RenderTabs creates a tab bar from section titles
-func RenderTabs(titles []string, activeTab int, width int) string {
+func (st *Styles) RenderTabs(titles []string, activeTab int, width int) string {
 	availWidth := width - 4 // Account for margins
 
 	var tabs []string
@@ -207,9 +264,9 @@ func RenderTabs(titles []string, activeTab int, width int) string {
 	for i, title := range titles {
 		var style lipgloss.Style
 		if i == activeTab {
-			style = ActiveTabStyle.Copy()
+			style = st.ActiveTab.Copy()
 		} else {
-			style = InactiveTabStyle.Copy()
+			style = st.InactiveTab.Copy()
 		}
 
 		// Ensure tab text fits
@@ -231,26 +288,26 @@ func RenderTabs(titles []string, activeTab int, width int) string {
 		return lipgloss.NewStyle().Width(availWidth).Render(tabBar)
 	}
 
-	return TabBarStyle.Width(availWidth).Render(tabBar)
+	return st.TabBar.Copy().Width(availWidth).Render(tabBar)
 }
 
 // RenderStatusBar creates a Neovim-like status bar
-func RenderStatusBar(mode string, message string, width int) string {
+func (st *Styles) RenderStatusBar(mode string, message string, width int) string {
 	if mode == "" {
 		mode = "NORMAL"
 	}
 
-	modeIndicator := ModeIndicatorStyle.Render(mode)
+	modeIndicator := st.ModeIndicator.Render(mode)
 
 	// Right side information (status message)
-	statusMsg := StatusMessageStyle.Render(message)
+	statusMsg := st.StatusMessage.Render(message)
 
 	// Calculate remaining space
 	remainingWidth := width - lipgloss.Width(modeIndicator) - lipgloss.Width(statusMsg)
 
 	// Create the padding
 	padding := lipgloss.NewStyle().
-		Background(SubtleColor).
+		Background(st.Subtle).
 		Width(remainingWidth).
 		Render()
 
@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+// Sessions render while an owner saves a new theme, run with -race
+func TestSetThemeWhileRendering(t *testing.T) {
+	portfolio := models.DefaultPortfolio()
+	theme := portfolio.Theme
+	theme.Primary = "#ff0000"
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(width int) {
+			defer wg.Done()
+			m := NewModel(portfolio, width, 40)
+			m.ShowWelcome = false
+			m.Broadcast = "hello"
+			for j := 0; j < 50; j++ {
+				m.View()
+			}
+		}(80 + i*10)
+	}
+	for j := 0; j < 50; j++ {
+		SetTheme(theme)
+		SetTheme(portfolio.Theme)
+	}
+	wg.Wait()
+}
+
+func TestSetThemeKeepsRenderedStyles(t *testing.T) {
+	defer SetTheme(models.DefaultPortfolio().Theme)
+
+	before := CurrentStyles()
+	theme := models.DefaultPortfolio().Theme
+	theme.Primary = "#ff0000"
+	SetTheme(theme)
+
+	if CurrentStyles() == before {
+		t.Fatal("SetTheme did not swap the styles")
+	}
+	if got := CurrentStyles().Primary; string(got) != "#ff0000" {
+		t.Errorf("Primary = %q, want #ff0000", got)
+	}
+	if before.Primary == "#ff0000" {
+		t.Error("SetTheme changed styles a session may still render with")
+	}
+}
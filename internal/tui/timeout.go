@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// reasons the program quit, read from the final model
+const (
+	ExitQuit        = "quit"
+	ExitIdle        = "idle"
+	ExitMaxDuration = "max_duration"
+	ExitKicked      = "kicked"
+)
+
+// message to re-check the idle and session deadlines
+type timeoutTickMsg time.Time
+
+// timeoutCheck schedules the next deadline check, every second while a
+// countdown is shown and otherwise when the next warning is due
+func (m Model) timeoutCheck(now time.Time) tea.Cmd {
+	next, ok := m.nextDeadline(now)
+	if !ok {
+		return nil
+	}
+
+	wait := next.Sub(now) - m.TimeoutWarning
+	if wait <= 0 {
+		wait = time.Second
+	}
+
+	return tea.Tick(wait, func(t time.Time) tea.Msg {
+		return timeoutTickMsg(t)
+	})
+}
+
+// nextDeadline returns the earliest of the idle and session deadlines
+func (m Model) nextDeadline(now time.Time) (time.Time, bool) {
+	var next time.Time
+	if m.IdleTimeout > 0 {
+		next = m.lastInput.Add(m.IdleTimeout)
+	}
+	if !m.Deadline.IsZero() && (next.IsZero() || m.Deadline.Before(next)) {
+		next = m.Deadline
+	}
+	return next, !next.IsZero()
+}
+
+// handleTimeoutTick quits when a deadline has passed and updates the
+// countdown warning when one is close
+func (m Model) handleTimeoutTick(now time.Time) (Model, tea.Cmd) {
+	if m.IdleTimeout > 0 && !now.Before(m.lastInput.Add(m.IdleTimeout)) {
+		m.ExitReason = ExitIdle
+		m.emitLeave()
+		return m, tea.Quit
+	}
+	if !m.Deadline.IsZero() && !now.Before(m.Deadline) {
+		m.ExitReason = ExitMaxDuration
+		m.emitLeave()
+		return m, tea.Quit
+	}
+
+	m.TimeoutMessage = ""
+	next, _ := m.nextDeadline(now)
+	if left := next.Sub(now); left <= m.TimeoutWarning {
+		secs := int(left.Round(time.Second).Seconds())
+		if m.IdleTimeout > 0 && next.Equal(m.lastInput.Add(m.IdleTimeout)) {
+			m.TimeoutMessage = fmt.Sprintf("Idle: disconnecting in %ds, press any key to stay", secs)
+		} else {
+			m.TimeoutMessage = fmt.Sprintf("Session time limit: disconnecting in %ds", secs)
+		}
+	}
+
+	return m, m.timeoutCheck(now)
+}
@@ -4,13 +4,15 @@ import (
 	"regexp"
 )
 
+// LinkPattern matches the URLs that are treated as links in content lines
+var LinkPattern = regexp.MustCompile(`https?://\S+`)
+
 // FindLinks extracts all URLs from a slice of content strings
 func FindLinks(content []string) []string {
 	links := []string{}
-	re := regexp.MustCompile(`https?://\S+`)
 
 	for _, line := range content {
-		matches := re.FindAllString(line, -1)
+		matches := LinkPattern.FindAllString(line, -1)
 		links = append(links, matches...)
 	}
 
@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+// lines of a changed section listed in the what's new panel
+const whatsNewLines = 3
+
+// OpenSection starts the session on the section titled title, reporting
+// false when there is none. Call it before the program runs.
+func (m *Model) OpenSection(title string) bool {
+	for i, sec := range m.Portfolio.Sections {
+		if sec.Title == title {
+			m.SectionCursor = i
+			m.Links = FindLinks(sec.Content)
+			return true
+		}
+	}
+	return false
+}
+
+// renderWhatsNew lists the changes since the visitor's last session
+func (m Model) renderWhatsNew(st *Styles, width int) string {
+	var b strings.Builder
+	b.WriteString(st.SectionHeader.Render("✦WHAT'S NEW SINCE YOUR LAST VISIT✦") + "\n")
+	b.WriteString(st.SectionDivider.Render(strings.Repeat("─", width/2)) + "\n\n")
+
+	// leave room for the header and the closing hint
+	room := ContentHeight - 5
+	for i, change := range m.WhatsNew {
+		if room < 2 {
+			b.WriteString(st.Inactive.Render(fmt.Sprintf("  and %d more sections", len(m.WhatsNew)-i)) + "\n")
+			break
+		}
+
+		var added []string
+		for _, line := range change.Added {
+			if strings.TrimSpace(line) != "" {
+				added = append(added, line)
+			}
+		}
+		var summary string
+		switch change.Kind {
+		case models.SectionAdded:
+			summary = "new section"
+		case models.SectionRemoved:
+			summary = "removed"
+		default:
+			var parts []string
+			if len(added) > 0 {
+				parts = append(parts, fmt.Sprintf("%d new or edited lines", len(added)))
+			}
+			if change.Removed > 0 {
+				parts = append(parts, fmt.Sprintf("%d removed", change.Removed))
+			}
+			summary = strings.Join(parts, ", ")
+		}
+		b.WriteString("  " + st.Focused.Render(change.Section) + st.Inactive.Render(" • "+summary) + "\n")
+		room--
+
+		for j, line := range added {
+			if j == whatsNewLines || room < 2 {
+				b.WriteString(st.Inactive.Render(fmt.Sprintf("      and %d more", len(added)-j)) + "\n")
+				room--
+				break
+			}
+			if r := []rune(line); len(r) > width-8 && width > 9 {
+				line = string(r[:width-9]) + "…"
+			}
+			b.WriteString("    + " + line + "\n")
+			room--
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,179 @@
+// Package visitors remembers returning visitors by the fingerprint of their
+// SSH key, along with every revision of the content they may have seen.
+package visitors
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/cankurttekin/sh.kurttekin.com/internal/models"
+)
+
+var (
+	visitsBucket    = []byte("visits")
+	revisionsBucket = []byte("revisions")
+)
+
+// Visit is what is remembered about one key
+type Visit struct {
+	First       time.Time `json:"first"`
+	Last        time.Time `json:"last"` // start of the latest session
+	Count       int       `json:"count"`
+	LastSection string    `json:"last_section,omitempty"` // section open when the latest session ended
+	Revision    uint64    `json:"revision"`               // content revision the latest session was shown
+}
+
+// Revision is one version of the content, hidden sections included
+type Revision struct {
+	Number  uint64           `json:"number"`
+	Time    time.Time        `json:"time"`
+	Content models.Portfolio `json:"content"`
+}
+
+// Store is a bbolt database of visits and content revisions. Like the
+// analytics store the file is opened per call.
+type Store struct {
+	path string
+}
+
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+func (st *Store) open() (*bolt.DB, error) {
+	db, err := bolt.Open(st.path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open visitor store %s: %w", st.path, err)
+	}
+	return db, nil
+}
+
+func (st *Store) update(fn func(tx *bolt.Tx) error) error {
+	db, err := st.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(fn)
+}
+
+func (st *Store) view(fn func(tx *bolt.Tx) error) error {
+	db, err := st.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.View(fn)
+}
+
+// Visit returns what is known about a key, false for a first visit
+func (st *Store) Visit(fingerprint string) (Visit, bool, error) {
+	var v Visit
+	var found bool
+	err := st.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket(visitsBucket)
+		if b == nil {
+			return nil
+		}
+		data := b.Get([]byte(fingerprint))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &v)
+	})
+	return v, found, err
+}
+
+// SaveVisit stores what is known about a key
+func (st *Store) SaveVisit(fingerprint string, v Visit) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return st.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(visitsBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(fingerprint), data)
+	})
+}
+
+// Count returns how many keys have visited
+func (st *Store) Count() (int, error) {
+	var n int
+	err := st.view(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(visitsBucket); b != nil {
+			n = b.Stats().KeyN
+		}
+		return nil
+	})
+	return n, err
+}
+
+// AddRevision records p as a new revision unless it equals the latest one,
+// and returns the number of the revision p is
+func (st *Store) AddRevision(p models.Portfolio) (uint64, error) {
+	content, err := json.Marshal(p)
+	if err != nil {
+		return 0, err
+	}
+
+	var number uint64
+	err = st.update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(revisionsBucket)
+		if err != nil {
+			return err
+		}
+
+		if k, v := b.Cursor().Last(); k != nil {
+			var latest struct {
+				Content json.RawMessage `json:"content"`
+			}
+			if err := json.Unmarshal(v, &latest); err == nil && bytes.Equal(latest.Content, content) {
+				number = binary.BigEndian.Uint64(k)
+				return nil
+			}
+		}
+
+		if number, err = b.NextSequence(); err != nil {
+			return err
+		}
+		data, err := json.Marshal(Revision{Number: number, Time: time.Now(), Content: p})
+		if err != nil {
+			return err
+		}
+		return b.Put(revisionKey(number), data)
+	})
+	return number, err
+}
+
+// Revision returns a revision by number, false when it is unknown
+func (st *Store) Revision(number uint64) (Revision, bool, error) {
+	var r Revision
+	var found bool
+	err := st.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket(revisionsBucket)
+		if b == nil {
+			return nil
+		}
+		data := b.Get(revisionKey(number))
+		if data == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(data, &r)
+	})
+	return r, found, err
+}
+
+// revisionKey is big endian so revisions sort in order
+func revisionKey(number uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, number)
+}